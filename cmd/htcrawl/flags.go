@@ -0,0 +1,418 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seaung/htcrawl-go"
+)
+
+type stringList struct {
+	list  *[]string
+	reset bool
+}
+
+func (l *stringList) String() string {
+	if l.list == nil {
+		return ""
+	}
+	return strings.Join(*l.list, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	if !l.reset {
+		*l.list = nil
+		l.reset = true
+	}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l.list = append(*l.list, v)
+		}
+	}
+	return nil
+}
+
+type repeatedString []string
+
+func (l *repeatedString) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *repeatedString) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type headerMap struct {
+	m *map[string]string
+}
+
+func (h headerMap) String() string {
+	if h.m == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*h.m))
+	for k, v := range *h.m {
+		parts = append(parts, k+": "+v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h headerMap) Set(value string) error {
+	kv := strings.SplitN(value, ":", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return fmt.Errorf("header must be in the form 'Name: value'")
+	}
+	if *h.m == nil {
+		*h.m = make(map[string]string)
+	}
+	(*h.m)[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	return nil
+}
+
+type cookieList struct {
+	cookies *[]htcrawl.Cookie
+}
+
+func (l cookieList) String() string {
+	if l.cookies == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*l.cookies))
+	for _, c := range *l.cookies {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (l cookieList) Set(value string) error {
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("cookie must be in the form 'name=value'")
+		}
+		*l.cookies = append(*l.cookies, htcrawl.Cookie{Name: kv[0], Value: kv[1], Path: "/"})
+	}
+	return nil
+}
+
+type eventsMapValue struct {
+	m     *map[string][]string
+	reset bool
+}
+
+func (v *eventsMapValue) String() string {
+	if v.m == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*v.m))
+	for k, evs := range *v.m {
+		parts = append(parts, k+"="+strings.Join(evs, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (v *eventsMapValue) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("event map must be in the form 'selector=event1,event2'")
+	}
+	if !v.reset || *v.m == nil {
+		*v.m = make(map[string][]string)
+		v.reset = true
+	}
+	events := make([]string, 0)
+	for _, ev := range strings.Split(kv[1], ",") {
+		if ev = strings.TrimSpace(ev); ev != "" {
+			events = append(events, ev)
+		}
+	}
+	(*v.m)[kv[0]] = events
+	return nil
+}
+
+type inputMatchList struct {
+	matches *[]htcrawl.InputMatch
+	reset   bool
+}
+
+func (l *inputMatchList) String() string {
+	if l.matches == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*l.matches))
+	for _, m := range *l.matches {
+		parts = append(parts, m.Name+"="+m.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l *inputMatchList) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return fmt.Errorf("input match must be in the form 'regexp=valuetype'")
+	}
+	if !l.reset {
+		*l.matches = (*l.matches)[:0]
+		l.reset = true
+	}
+	*l.matches = append(*l.matches, htcrawl.InputMatch{Name: kv[0], Value: kv[1]})
+	return nil
+}
+
+type localStorageList struct {
+	items *[]htcrawl.LocalstorageItem
+}
+
+func (l localStorageList) String() string {
+	if l.items == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*l.items))
+	for _, i := range *l.items {
+		parts = append(parts, i.Key+"="+i.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l localStorageList) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("localStorage item must be in the form 'key=value'")
+	}
+	*l.items = append(*l.items, htcrawl.LocalstorageItem{Key: kv[0], Value: kv[1]})
+	return nil
+}
+
+type windowSizeValue struct {
+	size *[]int
+}
+
+func (v windowSizeValue) String() string {
+	if v.size == nil || len(*v.size) != 2 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", (*v.size)[0], (*v.size)[1])
+}
+
+func (v windowSizeValue) Set(value string) error {
+	parts := strings.SplitN(strings.ToLower(value), "x", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("window size must be in the form WIDTHxHEIGHT")
+	}
+	w, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return err
+	}
+	h, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+	*v.size = []int{w, h}
+	return nil
+}
+
+type httpAuthValue struct {
+	auth *[]string
+}
+
+func (v httpAuthValue) String() string {
+	if v.auth == nil {
+		return ""
+	}
+	return strings.Join(*v.auth, ":")
+}
+
+func (v httpAuthValue) Set(value string) error {
+	kv := strings.SplitN(value, ":", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("http auth must be in the form 'user:password'")
+	}
+	*v.auth = kv
+	return nil
+}
+
+type intList struct {
+	values *[]int
+}
+
+func (l intList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*l.values))
+	for _, v := range *l.values {
+		parts = append(parts, strconv.Itoa(v))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l intList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+		*l.values = append(*l.values, n)
+	}
+	return nil
+}
+
+type exclusionList struct {
+	rules *[]htcrawl.ElementExclusion
+	kind  string
+}
+
+func (l exclusionList) String() string {
+	if l.rules == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*l.rules))
+	for _, r := range *l.rules {
+		switch l.kind {
+		case "selector":
+			parts = append(parts, r.Selector)
+		case "text":
+			parts = append(parts, r.Text)
+		case "href":
+			parts = append(parts, r.Href)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l exclusionList) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("exclusion must not be empty")
+	}
+	switch l.kind {
+	case "selector":
+		*l.rules = append(*l.rules, htcrawl.ElementExclusion{Selector: value})
+	case "text":
+		*l.rules = append(*l.rules, htcrawl.ElementExclusion{Text: value})
+	case "href":
+		*l.rules = append(*l.rules, htcrawl.ElementExclusion{Href: value})
+	}
+	return nil
+}
+
+type dangerousValue struct {
+	rules *[]htcrawl.ElementExclusion
+}
+
+func (v dangerousValue) String() string {
+	return "false"
+}
+
+func (v dangerousValue) IsBoolFlag() bool {
+	return true
+}
+
+func (v dangerousValue) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		*v.rules = append(*v.rules, htcrawl.DangerousElements...)
+	}
+	return nil
+}
+
+type customUIValue struct {
+	ui **htcrawl.CustomUI
+}
+
+func (v customUIValue) String() string {
+	if v.ui == nil || *v.ui == nil {
+		return ""
+	}
+	return (*v.ui).ExtensionPath
+}
+
+func (v customUIValue) Set(value string) error {
+	*v.ui = &htcrawl.CustomUI{ExtensionPath: value}
+	return nil
+}
+
+func registerOptionFlags(fs *flag.FlagSet, o *htcrawl.Options) {
+	fs.BoolVar(&o.Verbose, "verbose", o.Verbose, "enable verbose logging")
+	fs.BoolVar(&o.CheckAjax, "check-ajax", o.CheckAjax, "intercept XHR requests")
+	fs.BoolVar(&o.FillValues, "fill-values", o.FillValues, "fill input fields with generated values")
+	fs.BoolVar(&o.TriggerEvents, "trigger-events", o.TriggerEvents, "trigger events attached to elements")
+	fs.BoolVar(&o.CheckWebsockets, "check-websockets", o.CheckWebsockets, "intercept websocket connections")
+	fs.BoolVar(&o.SearchUrls, "search-urls", o.SearchUrls, "search for URLs in loaded scripts")
+	fs.BoolVar(&o.JsonOutput, "json-output", o.JsonOutput, "stream requests as JSONL")
+	fs.IntVar(&o.MaxExecTime, "max-exec-time", o.MaxExecTime, "maximum crawl time per target in milliseconds")
+	fs.IntVar(&o.AjaxTimeout, "ajax-timeout", o.AjaxTimeout, "time to wait for ajax requests in milliseconds")
+	fs.BoolVar(&o.PrintAjaxPostData, "print-ajax-post-data", o.PrintAjaxPostData, "include ajax POST data in output")
+	fs.BoolVar(&o.LoadImages, "load-images", o.LoadImages, "load images while crawling")
+	fs.BoolVar(&o.GetCookies, "get-cookies", o.GetCookies, "collect cookies")
+	fs.BoolVar(&o.MapEvents, "map-events", o.MapEvents, "map events attached to elements")
+	fs.BoolVar(&o.CheckScriptInsertion, "check-script-insertion", o.CheckScriptInsertion, "monitor script insertion")
+	fs.BoolVar(&o.CheckFetch, "check-fetch", o.CheckFetch, "intercept fetch requests")
+	fs.Var(httpAuthValue{&o.HttpAuth}, "http-auth", "HTTP basic auth credentials as user:password")
+	fs.BoolVar(&o.TriggerAllMappedEvents, "trigger-all-mapped-events", o.TriggerAllMappedEvents, "trigger all mapped events")
+	fs.BoolVar(&o.OutputMappedEvents, "output-mapped-events", o.OutputMappedEvents, "output mapped events")
+	fs.BoolVar(&o.OverrideTimeoutFunctions, "override-timeout-functions", o.OverrideTimeoutFunctions, "override setTimeout and setInterval")
+	fs.StringVar(&o.Referer, "referer", o.Referer, "referer header")
+	fs.StringVar(&o.UserAgent, "user-agent", o.UserAgent, "user agent")
+	fs.Var(&stringList{list: &o.AllEvents}, "all-events", "comma separated list of all events")
+	fs.Var(&stringList{list: &o.MouseEvents}, "mouse-events", "comma separated list of mouse events")
+	fs.Var(&stringList{list: &o.KeyboardEvents}, "keyboard-events", "comma separated list of keyboard events")
+	fs.Var(cookieList{&o.SetCookies}, "cookie", "cookie to set as name=value (repeatable)")
+	fs.Var((*repeatedString)(&o.ExcludedUrls), "exclude", "regexp of URLs to exclude (repeatable)")
+	fs.IntVar(&o.MaximumRecursion, "max-recursion", o.MaximumRecursion, "maximum recursion depth")
+	fs.IntVar(&o.MaximumAjaxChain, "max-ajax-chain", o.MaximumAjaxChain, "maximum ajax chain length")
+	fs.StringVar(&o.RandomSeed, "random-seed", o.RandomSeed, "seed for generated input values")
+	fs.StringVar(&o.Locale, "locale", o.Locale, "locale of generated input values (en_US, en_GB, it_IT, de_DE, fr_FR, es_ES, zh_CN)")
+	fs.Var(&inputMatchList{matches: &o.InputNameMatchValue}, "input-match", "input name regexp and value type as regexp=type (repeatable)")
+	fs.Var(&eventsMapValue{m: &o.EventsMap}, "events-map", "events to trigger per selector as selector=ev1,ev2 (repeatable)")
+	fs.StringVar(&o.Proxy, "proxy", o.Proxy, "proxy server")
+	fs.BoolVar(&o.LoadWithPost, "load-with-post", o.LoadWithPost, "load the target with a POST request")
+	fs.StringVar(&o.PostData, "post-data", o.PostData, "POST data used with -load-with-post")
+	fs.BoolVar(&o.HeadlessChrome, "headless", o.HeadlessChrome, "run chrome in headless mode")
+	fs.Var(headerMap{&o.ExtraHeaders}, "header", "extra header as 'Name: value' (repeatable)")
+	fs.BoolVar(&o.OpenChromeDevtools, "devtools", o.OpenChromeDevtools, "open chrome devtools")
+	fs.BoolVar(&o.ExceptionOnRedirect, "exception-on-redirect", o.ExceptionOnRedirect, "fail when the target redirects")
+	fs.IntVar(&o.NavigationTimeout, "navigation-timeout", o.NavigationTimeout, "navigation timeout in milliseconds")
+	fs.BoolVar(&o.BypassCSP, "bypass-csp", o.BypassCSP, "bypass content security policy")
+	fs.BoolVar(&o.SimulateRealEvents, "simulate-real-events", o.SimulateRealEvents, "dispatch real mouse events")
+	fs.StringVar(&o.CrawlMode, "crawl-mode", o.CrawlMode, "crawl mode (linear, random)")
+	fs.Var(localStorageList{&o.BrowserLocalstorage}, "local-storage", "localStorage item as key=value (repeatable)")
+	fs.BoolVar(&o.SkipDuplicateContent, "skip-duplicate-content", o.SkipDuplicateContent, "skip duplicated content")
+	fs.Var(windowSizeValue{&o.WindowSize}, "window-size", "browser window size as WIDTHxHEIGHT")
+	fs.BoolVar(&o.ShowUI, "show-ui", o.ShowUI, "show the crawler UI")
+	fs.Var(customUIValue{&o.CustomUI}, "custom-ui", "path of a custom UI extension")
+	fs.BoolVar(&o.OverridePostMessage, "override-post-message", o.OverridePostMessage, "intercept postMessage calls")
+	fs.StringVar(&o.LoggedOut.LoginURL, "logged-out-url", o.LoggedOut.LoginURL, "regexp of the login page URL that signals a lost session")
+	fs.StringVar(&o.LoggedOut.Selector, "logged-out-selector", o.LoggedOut.Selector, "CSS selector present only when logged out")
+	fs.StringVar(&o.LoggedOut.Text, "logged-out-text", o.LoggedOut.Text, "regexp of page text shown only when logged out")
+	fs.Var(intList{&o.LoggedOut.StatusCodes}, "logged-out-status", "comma separated HTTP statuses that signal a lost session")
+	fs.StringVar(&o.LoggedOut.StatusURL, "logged-out-status-url", o.LoggedOut.StatusURL, "regexp of URLs whose status is checked with -logged-out-status")
+	fs.StringVar(&o.LoggedOut.MissingCookie, "logged-out-cookie", o.LoggedOut.MissingCookie, "session cookie whose absence signals a lost session")
+	fs.IntVar(&o.MaxLoginAttempts, "max-login-attempts", o.MaxLoginAttempts, "maximum number of session restores")
+	fs.Var(exclusionList{&o.ExcludedElements, "selector"}, "exclude-element", "CSS selector of elements never triggered or filled (repeatable)")
+	fs.Var(exclusionList{&o.ExcludedElements, "text"}, "exclude-element-text", "regexp of element text, aria-label or title to skip (repeatable)")
+	fs.Var(exclusionList{&o.ExcludedElements, "href"}, "exclude-element-href", "regexp of link or form targets to skip (repeatable)")
+	fs.Var(dangerousValue{&o.ExcludedElements}, "exclude-dangerous", "skip logout and delete-like elements")
+	fs.Var((*repeatedString)(&o.UploadFiles), "upload-file", "file used to fill file inputs (repeatable)")
+	fs.BoolVar(&o.CheckMessageListeners, "check-message-listeners", o.CheckMessageListeners, "record postMessage listeners and received messages")
+	fs.BoolVar(&o.CheckSinks, "check-sinks", o.CheckSinks, "report values reaching DOM XSS sinks (use with -events sink)")
+	fs.BoolVar(&o.RecoverSourceMaps, "recover-source-maps", o.RecoverSourceMaps, "fetch source maps of loaded scripts and search their original sources")
+	fs.StringVar(&o.SourceMapDir, "source-map-dir", o.SourceMapDir, "directory where original sources from source maps are written")
+	fs.BoolVar(&o.CrawlRoutes, "crawl-routes", o.CrawlRoutes, "crawl client-side routes discovered through the history API and router tables")
+	fs.IntVar(&o.MaxRoutes, "max-routes", o.MaxRoutes, "maximum number of client-side routes to crawl (0 for no limit)")
+	fs.StringVar(&o.PopupMode, "popup-mode", o.PopupMode, "what to do with popups and new windows (block, child, allow)")
+	fs.BoolVar(&o.BypassServiceWorker, "bypass-service-worker", o.BypassServiceWorker, "send page requests to the network instead of through service workers")
+	fs.BoolVar(&o.Snapshots, "snapshots", o.Snapshots, "save a full-page screenshot and HTML snapshot of every new DOM state")
+	fs.BoolVar(&o.TriggerScreenshots, "trigger-screenshots", o.TriggerScreenshots, "save a screenshot of every element an event is triggered on")
+	fs.StringVar(&o.SnapshotDir, "snapshot-dir", o.SnapshotDir, "directory where screenshots and snapshots are written, named by content hash")
+	fs.BoolVar(&o.IncludeAllOrigins, "include-all-origins", o.IncludeAllOrigins, "disable site isolation to crawl all origins")
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaung/htcrawl-go"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitPartial = 3
+)
+
+var formats = []string{"jsonl", "har", "burp", "zap-urls", "postman", "curl", "openapi", "dot", "graphml", "graph-json"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	options := htcrawl.DefaultOptions()
+	fs, cli := newFlagSet(options, stderr)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if *cli.config != "" || *cli.profile != "" {
+		base := htcrawl.DefaultOptions()
+		if *cli.config != "" {
+			loaded, err := htcrawl.LoadOptions(*cli.config)
+			if err != nil {
+				fmt.Fprintf(stderr, "htcrawl: %v\n", err)
+				return exitUsage
+			}
+			base = loaded
+		}
+		if *cli.profile != "" {
+			if err := htcrawl.ApplyProfile(base, *cli.profile); err != nil {
+				fmt.Fprintf(stderr, "htcrawl: %v\n", err)
+				return exitUsage
+			}
+		}
+
+		options = base
+		fs, cli = newFlagSet(options, stderr)
+		if err := fs.Parse(args); err != nil {
+			return exitUsage
+		}
+	}
+
+	if err := options.Validate(); err != nil {
+		fmt.Fprintf(stderr, "htcrawl: invalid options: %v\n", err)
+		return exitUsage
+	}
+	format, output, events := cli.format, cli.output, cli.events
+
+	targets := fs.Args()
+	if len(targets) == 0 {
+		fmt.Fprintln(stderr, "htcrawl: at least one target URL is required")
+		fs.Usage()
+		return exitUsage
+	}
+
+	if !htcrawl.StringSliceContains(formats, *format) {
+		fmt.Fprintf(stderr, "htcrawl: unknown output format %q\n", *format)
+		return exitUsage
+	}
+
+	modes := 0
+	for _, enabled := range []bool{*cli.xss, *cli.pollution, *cli.redirect} {
+		if enabled {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintln(stderr, "htcrawl: -xss, -prototype-pollution and -open-redirect cannot be used together")
+		return exitUsage
+	}
+
+	if len(targets) > 1 && (*format == "dot" || *format == "graphml" || *format == "graph-json") && modes == 0 {
+		fmt.Fprintf(stderr, "htcrawl: the %s format only supports a single target\n", *format)
+		return exitUsage
+	}
+
+	if len(events) > 0 && *format != "jsonl" {
+		fmt.Fprintln(stderr, "htcrawl: -events can only be used with the jsonl format")
+		return exitUsage
+	}
+
+	out := stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "htcrawl: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		out = f
+	}
+
+	logger := log.New(stderr, "htcrawl: ", 0)
+	result := &crawlResult{graphs: make([]*htcrawl.StateGraph, 0)}
+	failed := 0
+
+	for _, target := range targets {
+		var err error
+		switch {
+		case *cli.xss:
+			err = scanTarget(target, options, out)
+		case *cli.pollution:
+			err = pollutionTarget(target, options, out)
+		case *cli.redirect:
+			err = redirectTarget(target, options, out)
+		default:
+			err = crawlTarget(target, options, *format, events, out, result)
+		}
+		if err != nil {
+			logger.Printf("%s: %v", target, err)
+			failed++
+		}
+	}
+
+	if modes == 0 {
+		if err := writeResult(*format, out, result); err != nil {
+			logger.Printf("failed to write output: %v", err)
+			return exitFailure
+		}
+	}
+
+	switch {
+	case failed == len(targets):
+		return exitFailure
+	case failed > 0:
+		return exitPartial
+	}
+	return exitOK
+}
+
+type cliFlags struct {
+	format    *string
+	output    *string
+	config    *string
+	profile   *string
+	xss       *bool
+	pollution *bool
+	redirect  *bool
+	events    []string
+}
+
+func newFlagSet(options *htcrawl.Options, stderr io.Writer) (*flag.FlagSet, *cliFlags) {
+	fs := flag.NewFlagSet("htcrawl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: htcrawl [flags] URL [URL...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	cli := &cliFlags{}
+	cli.format = fs.String("format", "jsonl", "output format: "+strings.Join(formats, ", "))
+	cli.output = fs.String("output", "-", "output file, - for stdout")
+	cli.config = fs.String("config", "", "load options from a JSON or YAML file")
+	cli.profile = fs.String("profile", "", "apply a named profile (fast, thorough, api-only)")
+	cli.pollution = fs.Bool("prototype-pollution", false, "check for client-side prototype pollution and write findings as JSONL instead of crawling")
+	cli.redirect = fs.Bool("open-redirect", false, "crawl, then confirm DOM-based open redirects and write findings as JSONL")
+	cli.xss = fs.Bool("xss", false, "scan for DOM XSS and write findings as JSONL instead of crawling")
+	fs.Var(&stringList{list: &cli.events}, "events", "comma separated list of events to write as JSONL (jsonl format only)")
+	registerOptionFlags(fs, options)
+	return fs, cli
+}
+
+type crawlResult struct {
+	requests  []*htcrawl.Request
+	exchanges []*htcrawl.Exchange
+	graphs    []*htcrawl.StateGraph
+	exporter  *htcrawl.Exporter
+}
+
+func crawlTarget(target string, options *htcrawl.Options, format string, events []string, out io.Writer, result *crawlResult) error {
+	opts := *options
+	opts.SetCookies = append([]htcrawl.Cookie(nil), options.SetCookies...)
+
+	crawler, err := htcrawl.Launch(target, &opts)
+	if err != nil {
+		return err
+	}
+	defer crawler.Close()
+
+	if format == "jsonl" {
+		crawler.SetOutput(out)
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		for _, name := range events {
+			if err := crawler.On(name, eventWriter(enc)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.MaxExecTime > 0 {
+		timer := time.AfterFunc(time.Duration(opts.MaxExecTime)*time.Millisecond, crawler.Stop)
+		defer timer.Stop()
+	}
+
+	if err := crawler.Start(); err != nil {
+		return err
+	}
+
+	result.requests = append(result.requests, crawler.Requests()...)
+	result.exchanges = append(result.exchanges, crawler.Exchanges()...)
+	result.graphs = append(result.graphs, crawler.StateGraph())
+	result.exporter = crawler.Exporter()
+	return nil
+}
+
+func scanTarget(target string, options *htcrawl.Options, out io.Writer) error {
+	opts := *options
+	opts.SetCookies = append([]htcrawl.Cookie(nil), options.SetCookies...)
+
+	scanner, err := htcrawl.NewXSSScanner(target, &opts, htcrawl.DefaultXSSScanOptions())
+	if err != nil {
+		return err
+	}
+	defer scanner.Close()
+
+	if opts.MaxExecTime > 0 {
+		timer := time.AfterFunc(time.Duration(opts.MaxExecTime)*time.Millisecond, scanner.Crawler().Stop)
+		defer timer.Stop()
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	findings, err := scanner.Scan()
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func pollutionTarget(target string, options *htcrawl.Options, out io.Writer) error {
+	opts := *options
+	opts.SetCookies = append([]htcrawl.Cookie(nil), options.SetCookies...)
+
+	crawler, err := htcrawl.Launch(target, &opts)
+	if err != nil {
+		return err
+	}
+	defer crawler.Close()
+
+	if opts.MaxExecTime > 0 {
+		timer := time.AfterFunc(time.Duration(opts.MaxExecTime)*time.Millisecond, crawler.Stop)
+		defer timer.Stop()
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	findings, err := crawler.CheckPrototypePollution(htcrawl.DefaultPollutionOptions())
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func eventWriter(enc *json.Encoder) htcrawl.EventCallback {
+	return func(event *htcrawl.Event, crawler *htcrawl.Crawler) (interface{}, error) {
+		params := make(map[string]interface{}, len(event.Params))
+		for k, v := range event.Params {
+			if req, ok := v.(*htcrawl.Request); ok {
+				v = htcrawl.NewJSONRequest(req)
+			}
+			params[k] = v
+		}
+		return nil, enc.Encode(map[string]interface{}{
+			"event":  event.Name,
+			"params": params,
+		})
+	}
+}
+
+func writeResult(format string, out io.Writer, result *crawlResult) error {
+	exporter := result.exporter
+	if exporter == nil {
+		exporter = &htcrawl.Exporter{}
+	}
+
+	switch format {
+	case "har":
+		return exporter.HAR(out, result.exchanges)
+	case "burp":
+		return exporter.BurpXML(out, result.exchanges)
+	case "zap-urls":
+		return htcrawl.URLList(out, result.exchanges)
+	case "postman":
+		return exporter.Postman("htcrawl", result.requests).WriteJSON(out)
+	case "curl":
+		return exporter.Curl(out, result.requests)
+	case "openapi":
+		return htcrawl.InferOpenAPI("htcrawl", result.exchanges).WriteJSON(out)
+	case "dot", "graphml", "graph-json":
+		for _, g := range result.graphs {
+			var err error
+			switch format {
+			case "dot":
+				err = g.WriteDOT(out)
+			case "graphml":
+				err = g.WriteGraphML(out)
+			default:
+				err = g.WriteJSON(out)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func redirectTarget(target string, options *htcrawl.Options, out io.Writer) error {
+	opts := *options
+	opts.SetCookies = append([]htcrawl.Cookie(nil), options.SetCookies...)
+	opts.CheckSinks = true
+
+	crawler, err := htcrawl.Launch(target, &opts)
+	if err != nil {
+		return err
+	}
+	defer crawler.Close()
+
+	if opts.MaxExecTime > 0 {
+		timer := time.AfterFunc(time.Duration(opts.MaxExecTime)*time.Millisecond, crawler.Stop)
+		defer timer.Stop()
+	}
+
+	if err := crawler.Start(); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	findings, err := crawler.CheckOpenRedirects(htcrawl.DefaultRedirectOptions())
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return err
+}
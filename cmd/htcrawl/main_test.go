@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/seaung/htcrawl-go"
+)
+
+func TestFlags(t *testing.T) {
+	options := htcrawl.DefaultOptions()
+	fs, cli := newFlagSet(options, &bytes.Buffer{})
+	err := fs.Parse([]string{
+		"-mouse-events", "click",
+		"-all-events", "click,input",
+		"-all-events", "change",
+		"-events", "xhr,fetch",
+		"-window-size", "800x600",
+		"-proxy", "http://127.0.0.1:8080",
+		"https://example.com",
+	})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(options.MouseEvents, []string{"click"}) {
+		t.Errorf("Expected mouse events to replace the defaults, got %v", options.MouseEvents)
+	}
+	if !reflect.DeepEqual(options.AllEvents, []string{"click", "input", "change"}) {
+		t.Errorf("Expected repeated all events to accumulate, got %v", options.AllEvents)
+	}
+	if !reflect.DeepEqual(options.KeyboardEvents, htcrawl.DefaultOptions().KeyboardEvents) {
+		t.Errorf("Expected keyboard events to keep the defaults, got %v", options.KeyboardEvents)
+	}
+	if !reflect.DeepEqual(cli.events, []string{"xhr", "fetch"}) {
+		t.Errorf("Unexpected events: %v", cli.events)
+	}
+	if !reflect.DeepEqual(options.WindowSize, []int{800, 600}) || options.Proxy != "http://127.0.0.1:8080" {
+		t.Errorf("Unexpected window size or proxy: %v %s", options.WindowSize, options.Proxy)
+	}
+	if !reflect.DeepEqual(fs.Args(), []string{"https://example.com"}) {
+		t.Errorf("Unexpected targets: %v", fs.Args())
+	}
+}
+
+func TestNilMapFlags(t *testing.T) {
+	options := htcrawl.DefaultOptions()
+	options.ExtraHeaders = nil
+	options.EventsMap = nil
+	fs, _ := newFlagSet(options, &bytes.Buffer{})
+	if err := fs.Parse([]string{"-header", "X-A: b", "-events-map", "a=click", "https://example.com"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if options.ExtraHeaders["X-A"] != "b" || !reflect.DeepEqual(options.EventsMap["a"], []string{"click"}) {
+		t.Errorf("Unexpected maps: %v %v", options.ExtraHeaders, options.EventsMap)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "at least one target URL is required"},
+		{[]string{"-format", "pdf", "https://example.com"}, "unknown output format"},
+		{[]string{"-format", "graphml", "https://a.example", "https://b.example"}, "only supports a single target"},
+		{[]string{"-format", "har", "-events", "xhr", "https://example.com"}, "-events can only be used"},
+		{[]string{"-xss", "-open-redirect", "https://example.com"}, "cannot be used together"},
+		{[]string{"-popup-mode", "tab", "https://example.com"}, "popupMode"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("run(%v) = %d, want %d", tt.args, code, exitUsage)
+		}
+		if !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("run(%v) stderr = %q, want %q", tt.args, stderr.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+var Profiles = map[string]func(*Options){
+	"fast": func(o *Options) {
+		o.AjaxTimeout = 1000
+		o.MaxExecTime = 60000
+		o.MaximumRecursion = 5
+		o.MaximumAjaxChain = 10
+		o.TriggerAllMappedEvents = false
+		o.SkipDuplicateContent = true
+		o.EventsMap = map[string][]string{
+			"a":      {"click"},
+			"button": {"click"},
+			"input":  {"change", "click"},
+			"select": {"change"},
+		}
+	},
+	"thorough": func(o *Options) {
+		o.AjaxTimeout = 5000
+		o.MaxExecTime = 1800000
+		o.MaximumRecursion = 30
+		o.MaximumAjaxChain = 60
+		o.TriggerAllMappedEvents = true
+		o.SkipDuplicateContent = false
+		o.OverridePostMessage = true
+		o.CheckScriptInsertion = true
+	},
+	"api-only": func(o *Options) {
+		o.CheckAjax = true
+		o.CheckFetch = true
+		o.CheckWebsockets = true
+		o.LoadImages = false
+		o.ExcludedUrls = append(o.ExcludedUrls, `\.(css|png|jpe?g|gif|svg|ico|woff2?|ttf|eot|mp4|webm)(\?|$)`)
+	},
+}
+
+func ApplyProfile(o *Options, name string) error {
+	profile, ok := Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(Profiles))
+		for n := range Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	profile(o)
+	return nil
+}
+
+type configFile struct {
+	Profile  string `json:"profile" yaml:"profile"`
+	*Options `yaml:",inline"`
+}
+
+func LoadOptions(path string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := "json"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	}
+
+	o, err := ParseOptions(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return o, nil
+}
+
+func ParseOptions(data []byte, format string) (*Options, error) {
+	unmarshal := json.Unmarshal
+	if format == "yaml" {
+		unmarshal = yaml.Unmarshal
+	}
+
+	var head struct {
+		Profile string `json:"profile" yaml:"profile"`
+	}
+	if err := unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", format, err)
+	}
+	profile, err := ExpandEnv(head.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	o := DefaultOptions()
+	if profile != "" {
+		if err := ApplyProfile(o, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys map[string]interface{}
+	if err := unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", format, err)
+	}
+	clearConfiguredMaps(o, keys, format)
+
+	if err := unmarshal(data, &configFile{Options: o}); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", format, err)
+	}
+
+	if err := expandEnvFields(o); err != nil {
+		return nil, err
+	}
+
+	if err := ApplyEnv(o, "HTCRAWL_"); err != nil {
+		return nil, err
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func ExpandEnv(s string) (string, error) {
+	missing := make([]string, 0)
+	out := expandEnv(s, &missing)
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(RemoveDuplicateStrings(missing), ", "))
+	}
+	return out, nil
+}
+
+func expandEnv(s string, missing *[]string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := envVarPattern.FindStringSubmatch(m)
+		if v, ok := os.LookupEnv(sub[1]); ok {
+			return v
+		}
+		if strings.Contains(m, ":-") {
+			return sub[2]
+		}
+		*missing = append(*missing, sub[1])
+		return ""
+	})
+}
+
+func clearConfiguredMaps(o *Options, keys map[string]interface{}, format string) {
+	v := reflect.ValueOf(o).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get(format), ",")
+		if _, ok := keys[name]; ok && name != "" && v.Field(i).Kind() == reflect.Map {
+			v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+		}
+	}
+}
+
+func expandEnvFields(o *Options) error {
+	missing := make([]string, 0)
+	expandEnvValue(reflect.ValueOf(o).Elem(), &missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("undefined environment variables: %s", strings.Join(RemoveDuplicateStrings(missing), ", "))
+	}
+	return nil
+}
+
+func expandEnvValue(v reflect.Value, missing *[]string) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(expandEnv(v.String(), missing))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandEnvValue(v.Elem(), missing)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				expandEnvValue(v.Field(i), missing)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandEnvValue(v.Index(i), missing)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			expandEnvValue(elem, missing)
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Interface:
+		if s, ok := v.Interface().(string); ok && v.CanSet() {
+			v.Set(reflect.ValueOf(expandEnv(s, missing)))
+		}
+	}
+}
+
+func ApplyEnv(o *Options, prefix string) error {
+	v := reflect.ValueOf(o).Elem()
+	t := v.Type()
+	errs := make(ValidationErrors, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		value, ok := os.LookupEnv(prefix + envName(name))
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		var err error
+		switch field.Kind() {
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(value)
+			field.SetBool(b)
+		case reflect.Int:
+			var n int
+			n, err = strconv.Atoi(value)
+			field.SetInt(int64(n))
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+				parts := strings.Split(value, ",")
+				field.Set(reflect.ValueOf(parts))
+				break
+			}
+			fallthrough
+		default:
+			ptr := reflect.New(field.Type())
+			if err = json.Unmarshal([]byte(value), ptr.Interface()); err == nil {
+				field.Set(ptr.Elem())
+			}
+		}
+		if err != nil {
+			errs = append(errs, &FieldError{Field: name, Message: fmt.Sprintf("invalid value in %s: %v", prefix+envName(name), err)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func envName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToUpper(sb.String())
+}
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (o *Options) Validate() error {
+	errs := make(ValidationErrors, 0)
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if o.MaxExecTime < 0 {
+		add("maxExecTime", "must not be negative")
+	}
+	if o.AjaxTimeout < 0 {
+		add("ajaxTimeout", "must not be negative")
+	}
+	if o.NavigationTimeout <= 0 {
+		add("navigationTimeout", "must be greater than 0")
+	}
+	if o.MaximumRecursion < 0 {
+		add("maximumRecursion", "must not be negative")
+	}
+	if o.MaximumAjaxChain < 0 {
+		add("maximumAjaxChain", "must not be negative")
+	}
+	if len(o.HttpAuth) != 0 && len(o.HttpAuth) != 2 {
+		add("httpAuth", "must contain a username and a password")
+	}
+	if o.CrawlMode != "linear" && o.CrawlMode != "random" {
+		add("crawlMode", "must be one of linear, random")
+	}
+	if len(o.WindowSize) != 2 || o.WindowSize[0] <= 0 || o.WindowSize[1] <= 0 {
+		add("windowSize", "must be [width, height] with positive values")
+	}
+	if o.Proxy != "" {
+		if u, err := url.Parse(o.Proxy); err != nil || u.Host == "" {
+			add("proxy", "must be a URL such as http://host:port")
+		}
+	}
+	if o.Referer != "" {
+		if _, err := url.Parse(o.Referer); err != nil {
+			add("referer", "invalid URL: %v", err)
+		}
+	}
+	for i, pattern := range o.ExcludedUrls {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add(fmt.Sprintf("excludedUrls[%d]", i), "invalid regexp: %v", err)
+		}
+	}
+	for i, m := range o.InputNameMatchValue {
+		if _, err := regexp.Compile(m.Name); err != nil {
+			add(fmt.Sprintf("inputNameMatchValue[%d].name", i), "invalid regexp: %v", err)
+		}
+		if m.Value == "" {
+			add(fmt.Sprintf("inputNameMatchValue[%d].value", i), "must not be empty")
+		}
+	}
+	for i, c := range o.SetCookies {
+		if c.Name == "" {
+			add(fmt.Sprintf("setCookies[%d].name", i), "must not be empty")
+		}
+	}
+	for i, item := range o.BrowserLocalstorage {
+		if item.Key == "" {
+			add(fmt.Sprintf("browserLocalstorage[%d].key", i), "must not be empty")
+		}
+	}
+	if _, ok := Locales[o.Locale]; o.Locale != "" && !ok {
+		names := make([]string, 0, len(Locales))
+		for name := range Locales {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		add("locale", "must be one of %s", strings.Join(names, ", "))
+	}
+	if o.MaxLoginAttempts < 0 {
+		add("maxLoginAttempts", "must not be negative")
+	}
+	for _, f := range [][2]string{
+		{"loggedOut.loginUrl", o.LoggedOut.LoginURL},
+		{"loggedOut.text", o.LoggedOut.Text},
+		{"loggedOut.statusUrl", o.LoggedOut.StatusURL},
+	} {
+		if _, err := regexp.Compile(f[1]); err != nil {
+			add(f[0], "invalid regexp: %v", err)
+		}
+	}
+	for i, code := range o.LoggedOut.StatusCodes {
+		if code < 100 || code > 599 {
+			add(fmt.Sprintf("loggedOut.statusCodes[%d]", i), "invalid HTTP status %d", code)
+		}
+	}
+	for i, rule := range o.ExcludedElements {
+		field := fmt.Sprintf("excludedElements[%d]", i)
+		if rule.Selector == "" && rule.Text == "" && rule.Href == "" && len(rule.Attributes) == 0 {
+			add(field, "must set at least one of selector, text, href, attributes")
+		}
+		for _, f := range [][2]string{{"text", rule.Text}, {"href", rule.Href}} {
+			if _, err := regexp.Compile(f[1]); err != nil {
+				add(field+"."+f[0], "invalid regexp: %v", err)
+			}
+		}
+		names := make([]string, 0, len(rule.Attributes))
+		for name := range rule.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := regexp.Compile(rule.Attributes[name]); err != nil {
+				add(field+".attributes."+name, "invalid regexp: %v", err)
+			}
+		}
+	}
+	for i, path := range o.UploadFiles {
+		if info, err := os.Stat(path); err != nil {
+			add(fmt.Sprintf("uploadFiles[%d]", i), "%v", err)
+		} else if info.IsDir() {
+			add(fmt.Sprintf("uploadFiles[%d]", i), "%s is a directory", path)
+		}
+	}
+	if o.MaxRoutes < 0 {
+		add("maxRoutes", "must not be negative")
+	}
+	if o.PopupMode != PopupBlock && o.PopupMode != PopupChild && o.PopupMode != PopupAllow {
+		add("popupMode", "must be one of block, child, allow")
+	}
+	if o.SourceMapDir != "" {
+		if !o.RecoverSourceMaps {
+			add("sourceMapDir", "requires recoverSourceMaps")
+		} else if info, err := os.Stat(o.SourceMapDir); err == nil && !info.IsDir() {
+			add("sourceMapDir", "%s is not a directory", o.SourceMapDir)
+		}
+	}
+	if o.Snapshots || o.TriggerScreenshots {
+		if o.SnapshotDir == "" {
+			add("snapshotDir", "required by snapshots and triggerScreenshots")
+		} else if info, err := os.Stat(o.SnapshotDir); err == nil && !info.IsDir() {
+			add("snapshotDir", "%s is not a directory", o.SnapshotDir)
+		}
+	} else if o.SnapshotDir != "" {
+		add("snapshotDir", "requires snapshots or triggerScreenshots")
+	}
+	selectors := make([]string, 0, len(o.EventsMap))
+	for selector := range o.EventsMap {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+	for _, selector := range selectors {
+		if strings.TrimSpace(selector) == "" {
+			add("eventsMap", "selectors must not be empty")
+		}
+		if len(o.EventsMap[selector]) == 0 {
+			add(fmt.Sprintf("eventsMap[%s]", selector), "must list at least one event")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
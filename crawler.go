@@ -2,6 +2,7 @@ package htcrawl
 
 import (
 	"context"
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,9 +15,20 @@ import (
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
 )
 
+//go:embed probe.js
+var probeScript string
+
+//go:embed sinks.js
+var sinksScript string
+
+//go:embed messages.js
+var messagesScript string
+
 type EventCallback func(event *Event, crawler *Crawler) (interface{}, error)
 
 type Event struct {
@@ -51,6 +63,26 @@ type Crawler struct {
 	uiEvents           map[string]EventCallback
 	mu                 sync.RWMutex
 	documentElement    *rod.Element
+	requestWriter      *RequestWriter
+	network            *NetworkRecorder
+	stateGraph         *StateGraph
+	requests           *RequestCollector
+	sinks              *SinkCollector
+	scripts            *ScriptCollector
+	routes             *RouteCollector
+	workers            *WorkerCollector
+	snapshots          *SnapshotStore
+	frames             []*childFrame
+	popups             []*popupPage
+	crawlingPopups     bool
+	login              LoginFunc
+	loginAttempts      int
+	relogins           int
+	restoredCookies    []Cookie
+	sessionLost        string
+	inputValues        map[string]string
+	uploads            *UploadCorpus
+	valueProvider      ValueProvider
 	status             struct {
 		layer      string
 		curElement string
@@ -64,6 +96,10 @@ func Launch(targetURL string, options *Options) (*Crawler, error) {
 
 	targetURL = NormalizeURL(targetURL)
 
+	if (options.Snapshots || options.TriggerScreenshots) && options.SnapshotDir == "" {
+		return nil, fmt.Errorf("snapshotDir is required by snapshots and triggerScreenshots")
+	}
+
 	if options.ShowUI {
 		options.OpenChromeDevtools = true
 	}
@@ -106,6 +142,15 @@ func Launch(targetURL string, options *Options) (*Crawler, error) {
 		launcherPath = launcherPath.Devtools(true)
 	}
 
+	for _, arg := range chromeArgs {
+		name, value, ok := strings.Cut(arg, "=")
+		if ok {
+			launcherPath = launcherPath.Set(flags.Flag(name), value)
+		} else {
+			launcherPath = launcherPath.Set(flags.Flag(name))
+		}
+	}
+
 	browserURL, err := launcherPath.Launch()
 	if err != nil {
 		return nil, fmt.Errorf("failed to launch browser: %w", err)
@@ -132,6 +177,15 @@ func Launch(targetURL string, options *Options) (*Crawler, error) {
 		stop:            false,
 		probeEvents:     make(map[string]EventCallback),
 		uiEvents:        make(map[string]EventCallback),
+		network:         NewNetworkRecorder(),
+		stateGraph:      NewStateGraph(),
+		requests:        NewRequestCollector(),
+		sinks:           NewSinkCollector(),
+		scripts:         NewScriptCollector(),
+		routes:          NewRouteCollector(),
+		workers:         NewWorkerCollector(),
+		snapshots:       NewSnapshotStore(options.SnapshotDir),
+		uploads:         NewUploadCorpus(options.UploadFiles),
 	}
 
 	if err := crawler.bootstrapPage(); err != nil {
@@ -140,15 +194,8 @@ func Launch(targetURL string, options *Options) (*Crawler, error) {
 	}
 
 	go crawler.requestLoop()
-
-	browser.EachEvent(func(e *proto.TargetTargetCreated) {
-		if !crawler.allowNewWindows && e.TargetInfo.Type == "page" {
-			page, err := crawler.browser.Page(proto.TargetCreateTarget{})
-			if err == nil {
-				page.Close()
-			}
-		}
-	})
+	crawler.watchPopups()
+	crawler.watchWorkers()
 
 	return crawler, nil
 }
@@ -162,14 +209,15 @@ func (c *Crawler) Page() *rod.Page {
 }
 
 func (c *Crawler) Cookies() ([]Cookie, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cookies, err := c.page.Cookies([]string{})
 	if err != nil {
 		return c.cookies, err
 	}
 
+	c.cookies = make([]Cookie, 0, len(cookies))
 	for _, cookie := range cookies {
 		c.cookies = append(c.cookies, Cookie{
 			Name:     cookie.Name,
@@ -197,6 +245,28 @@ func (c *Crawler) Errors() [][2]string {
 	return c.errors
 }
 
+func (c *Crawler) Requests() []*Request {
+	return c.requests.GetAll()
+}
+
+func (c *Crawler) Sinks() []*Sink {
+	return c.sinks.GetAll()
+}
+
+func (c *Crawler) Exchanges() []*Exchange {
+	return c.network.Exchanges()
+}
+
+func (c *Crawler) SetOutput(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w == nil {
+		c.requestWriter = nil
+		return
+	}
+	c.requestWriter = NewRequestWriter(w)
+}
+
 func (c *Crawler) On(eventName string, handler EventCallback) error {
 	eventName = strings.ToLower(eventName)
 	validEvents := map[string]bool{
@@ -207,6 +277,8 @@ func (c *Crawler) On(eventName string, handler EventCallback) error {
 		"navigation": true, "domcontentloaded": true, "redirect": true,
 		"earlydetach": true, "triggerevent": true, "eventtriggered": true,
 		"pageinitialized": true, "crawlelement": true, "postmessage": true,
+		"sessionlost": true, "excludedelement": true, "sink": true,
+		"route": true, "worker": true, "snapshot": true,
 	}
 
 	if !validEvents[eventName] {
@@ -217,6 +289,10 @@ func (c *Crawler) On(eventName string, handler EventCallback) error {
 		return fmt.Errorf("overridePostMessage option must be true to use 'postmessage'")
 	}
 
+	if eventName == "sink" && !c.options.CheckSinks {
+		return fmt.Errorf("checkSinks option must be true to use 'sink'")
+	}
+
 	c.mu.Lock()
 	c.probeEvents[eventName] = handler
 	c.mu.Unlock()
@@ -233,6 +309,8 @@ func (c *Crawler) RemoveEvent(eventName string) error {
 		"navigation": true, "domcontentloaded": true, "redirect": true,
 		"earlydetach": true, "triggerevent": true, "eventtriggered": true,
 		"pageinitialized": true, "crawlelement": true, "postmessage": true,
+		"sessionlost": true, "excludedelement": true, "sink": true,
+		"route": true, "worker": true, "snapshot": true,
 	}
 
 	if !validEvents[eventName] {
@@ -287,12 +365,21 @@ func (c *Crawler) afterNavigation(resp *proto.NetworkResponse) error {
 	}
 
 	c.waitForRequestsCompletion()
+	snapshot := c.recordState(c.page, nil)
 
 	if c.isEventRegistered("pageinitialized") {
-		c.dispatchProbeEvent("pageinitialized", map[string]interface{}{})
+		params := map[string]interface{}{}
+		if snapshot != nil {
+			params["snapshot"] = snapshot
+		}
+		c.dispatchProbeEvent("pageinitialized", params)
 	}
 
-	if err := c.startMutationObserver(); err != nil {
+	if err := c.applyExclusions(c.page); err != nil {
+		return err
+	}
+
+	if err := c.startMutationObserver(c.page); err != nil {
 		return err
 	}
 
@@ -300,8 +387,12 @@ func (c *Crawler) afterNavigation(resp *proto.NetworkResponse) error {
 }
 
 func (c *Crawler) waitForRequestsCompletion() {
+	c.waitForPageRequests(c.page)
+}
+
+func (c *Crawler) waitForPageRequests(page *rod.Page) {
 	c.waitForRequests()
-	c.page.Eval(`() => {
+	page.Eval(`() => {
 		return Promise.all([
 			window.__PROBE__ ? window.__PROBE__.waitJsonp() : Promise.resolve(),
 			window.__PROBE__ ? window.__PROBE__.waitWebsocket() : Promise.resolve()
@@ -350,6 +441,11 @@ func (c *Crawler) Start() error {
 	if err := c.crawlDOM(nil); err != nil {
 		return err
 	}
+	c.crawlPopups()
+
+	if c.options.CrawlRoutes {
+		return c.crawlRoutes()
+	}
 
 	return nil
 }
@@ -361,6 +457,7 @@ func (c *Crawler) Stop() {
 }
 
 func (c *Crawler) Close() error {
+	defer c.uploads.Close()
 	return c.browser.Close()
 }
 
@@ -371,8 +468,33 @@ func (c *Crawler) isEventRegistered(event string) bool {
 	return ok
 }
 
+var requestEvents = map[string]bool{
+	"xhr": true, "fetch": true, "jsonp": true, "websocket": true,
+	"formsubmit": true, "navigation": true,
+}
+
 func (c *Crawler) dispatchProbeEvent(name string, params map[string]interface{}) (interface{}, error) {
 	name = strings.ToLower(name)
+	if req := requestFromParams(params); req != nil {
+		c.mu.RLock()
+		if t := c.trigger; t != nil && req.Trigger != nil && req.Trigger.Screenshot == "" &&
+			req.Trigger.Element == t.Element && req.Trigger.Event == t.Event {
+			req.Trigger.Screenshot = t.Screenshot
+		}
+		c.mu.RUnlock()
+		params["request"] = req
+	}
+	if name == "sink" {
+		if sink := sinkFromParams(params); sink != nil {
+			params["sink"] = sink
+		}
+	}
+	if name == "route" {
+		if route := routeFromParams(params); route != nil {
+			params["route"] = route
+		}
+	}
+
 	evt := &Event{
 		Name:   name,
 		Params: params,
@@ -382,20 +504,132 @@ func (c *Crawler) dispatchProbeEvent(name string, params map[string]interface{})
 	handler, ok := c.probeEvents[name]
 	c.mu.RUnlock()
 
+	var ret interface{} = true
+	if ok {
+		var err error
+		ret, err = handler(evt, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ret == false {
+		return false, nil
+	}
+
+	if req, ok := params["request"].(*Request); ok && requestEvents[name] {
+		c.requests.Add(req)
+		c.stateGraph.AddRequest(req)
+	}
+
+	if sink, ok := params["sink"].(*Sink); ok && name == "sink" {
+		c.sinks.Add(sink)
+	}
+
+	if route, ok := params["route"].(*Route); ok && name == "route" {
+		c.routes.Add(route)
+	}
+
+	if err := c.outputRequest(name, params); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (c *Crawler) outputRequest(name string, params map[string]interface{}) error {
+	if !c.options.JsonOutput || !requestEvents[name] {
+		return nil
+	}
+
+	c.mu.RLock()
+	rw := c.requestWriter
+	c.mu.RUnlock()
+
+	if rw == nil {
+		return nil
+	}
+
+	req, ok := params["request"].(*Request)
+	if !ok {
+		return nil
+	}
+	_, err := rw.Write(req)
+	return err
+}
+
+func (c *Crawler) handleBridgeCall(page *rod.Page, ctx proto.RuntimeExecutionContextID, payload gson.JSON) (interface{}, error) {
+	name := payload.Get("name").Str()
+	params, ok := payload.Get("params").Val().(map[string]interface{})
 	if !ok {
-		return true, nil
+		params = map[string]interface{}{}
 	}
+	return c.dispatchProbeEvent(name, params)
+}
 
-	ret, err := handler(evt, c)
-	if err != nil {
+func (c *Crawler) handleInputValue(page *rod.Page, ctx proto.RuntimeExecutionContextID, payload gson.JSON) (interface{}, error) {
+	var field InputField
+	if err := json.Unmarshal([]byte(payload.JSON("", "")), &field); err != nil {
 		return nil, err
 	}
 
-	if ret == false {
-		return false, nil
+	c.mu.RLock()
+	provider := c.valueProvider
+	c.mu.RUnlock()
+
+	v, ok := "", false
+	if provider != nil {
+		v, ok = provider.Value(&field)
+	}
+	if !ok {
+		defaults := &DefaultValueProvider{Matches: c.options.InputNameMatchValue, Values: c.inputValues, Uploads: c.uploads}
+		v, ok = defaults.Value(&field)
+	}
+	if !ok {
+		return nil, nil
+	}
+	if field.Type == "file" {
+		if v, ok = c.uploads.Marker(v); !ok {
+			return nil, nil
+		}
+	}
+	return v, nil
+}
+
+func (c *Crawler) SetValueProvider(provider ValueProvider) {
+	c.mu.Lock()
+	c.valueProvider = provider
+	c.mu.Unlock()
+}
+
+func (c *Crawler) handleSetFiles(page *rod.Page, ctx proto.RuntimeExecutionContextID, payload gson.JSON) (interface{}, error) {
+	file, ok := c.uploads.Resolve(payload.Get("file").Str())
+	if !ok {
+		return false, fmt.Errorf("unknown upload %q", TruncateString(payload.Get("file").Str(), 200))
+	}
+	selector, err := json.Marshal(payload.Get("element").Str())
+	if err != nil {
+		return false, err
+	}
+	res, err := proto.RuntimeEvaluate{
+		Expression: fmt.Sprintf("window.__PROBE__ ? window.__PROBE__.querySelectorAllDeep(document, %s)[0] : null", selector),
+		ContextID:  ctx,
+	}.Call(page)
+	if err != nil {
+		return false, err
+	}
+	if res.Result.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+		return false, &rod.ErrElementNotFound{}
+	}
+	el, err := page.ElementFromObject(res.Result)
+	if err != nil {
+		return false, err
 	}
 
-	return ret, nil
+	if err := el.SetFiles([]string{file}); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (c *Crawler) requestLoop() {
@@ -418,11 +652,10 @@ func parseContentLength(s string) (int64, error) {
 }
 
 func (c *Crawler) readProbeScript() (string, error) {
-	content, err := os.ReadFile("probe.js")
-	if err != nil {
-		return "", err
+	if probeScript == "" {
+		return "", fmt.Errorf("probe script is empty")
 	}
-	return string(content), nil
+	return probeScript, nil
 }
 
 func (c *Crawler) bootstrapPage() error {
@@ -432,6 +665,22 @@ func (c *Crawler) bootstrapPage() error {
 	}
 
 	c.page = page
+	c.mu.Lock()
+	c.frames = nil
+	c.mu.Unlock()
+	c.network.Attach(page)
+	c.watchSession(page)
+	c.watchServiceWorkers(page)
+
+	if c.options.BypassServiceWorker {
+		if err := (proto.NetworkSetBypassServiceWorker{Bypass: true}).Call(page); err != nil {
+			return fmt.Errorf("failed to bypass service workers: %w", err)
+		}
+	}
+
+	if err := c.watchScripts(page); err != nil {
+		return fmt.Errorf("failed to watch scripts: %w", err)
+	}
 
 	if err := c.setupRequestInterception(); err != nil {
 		return fmt.Errorf("failed to setup request interception: %w", err)
@@ -445,7 +694,14 @@ func (c *Crawler) bootstrapPage() error {
 		return fmt.Errorf("failed to setup probe script: %w", err)
 	}
 
-	if err := c.setupHeadersAndCookies(); err != nil {
+	if err := c.watchFrames(page); err != nil {
+		return fmt.Errorf("failed to watch frames: %w", err)
+	}
+
+	c.mu.RLock()
+	restored := c.restoredCookies
+	c.mu.RUnlock()
+	if err := c.setupHeadersAndCookies(restored); err != nil {
 		return fmt.Errorf("failed to setup headers and cookies: %w", err)
 	}
 
@@ -481,76 +737,104 @@ func (c *Crawler) setupDialogHandler() error {
 }
 
 func (c *Crawler) setupProbeScript() error {
-	probeScript, err := c.readProbeScript()
+	c.inputValues = NewFaker(c.options.RandomSeed, c.options.Locale).Values()
+	return c.injectProbe(c.page, true)
+}
+
+func (c *Crawler) injectProbe(page *rod.Page, evaluate bool) error {
+	initScript, err := c.probeInitScript(page)
 	if err != nil {
 		return err
 	}
 
+	if _, err := page.EvalOnNewDocument(initScript); err != nil {
+		return err
+	}
+
+	if !evaluate {
+		return nil
+	}
+	_, err = page.Eval(fmt.Sprintf(`() => { %s }`, initScript))
+	return err
+}
+
+func (c *Crawler) probeInitScript(page *rod.Page) (string, error) {
+	probeScript, err := c.readProbeScript()
+	if err != nil {
+		return "", err
+	}
+
 	optionsJSON, err := json.Marshal(c.options)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	inputValues := GenerateRandomValues(c.options.RandomSeed)
-	inputValuesJSON, err := json.Marshal(inputValues)
+	inputValuesJSON, err := json.Marshal(c.inputValues)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	script := fmt.Sprintf(`
-		%s
-		window.__htcrawl_probe_event__ = async function(name, params) {
-			return window.__htcrawl_go_bridge__(name, params);
-		};
-	`, probeScript)
+	bridge, err := c.exposeBridge(page)
+	if err != nil {
+		return "", err
+	}
 
-	if _, err := c.page.EvalOnNewDocument(script); err != nil {
-		return err
+	sinks := ""
+	if c.options.CheckSinks {
+		sinks = sinksScript
 	}
 
-	initScript := fmt.Sprintf(`
+	messages := ""
+	if c.options.CheckMessageListeners || c.options.OverridePostMessage {
+		messages = messagesScript
+	}
+
+	return fmt.Sprintf(`
+		%s
+		window.__htcrawl_probe_event__ = async function(name, params) {
+			if (window !== window.top && params && typeof params == "object" && !params.frame) {
+				params.frame = location.href;
+			}
+			return window.__htcrawl_go_bridge__({ name: name, params: params });
+		};
 		(function() {
 			var options = %s;
 			var inputValues = %s;
 			%s
 		})();
-	`, string(optionsJSON), string(inputValuesJSON), probeScript)
-
-	_, err = c.page.Eval(initScript)
-	return err
+		%s
+		%s
+	`, bridge, string(optionsJSON), string(inputValuesJSON), probeScript, sinks, messages), nil
 }
 
-func (c *Crawler) setupHeadersAndCookies() error {
-	for i := range c.options.SetCookies {
-		cookie := c.options.SetCookies[i]
-		if cookie.Expires == 0 {
-			cookie.Expires = time.Now().Unix() + 60*60*24*365
-		}
-		if cookie.URL == "" || cookie.Domain == "" {
+func (c *Crawler) setupHeadersAndCookies(restored []Cookie) error {
+	cookies := make([]Cookie, 0, len(c.options.SetCookies))
+	for _, cookie := range c.options.SetCookies {
+		if cookie.Domain == "" {
 			parsedURL, err := url.Parse(c.targetUrl)
 			if err == nil {
 				cookie.Domain = parsedURL.Hostname()
 				cookie.URL = c.targetUrl
 			}
 		}
-		if cookie.Value == "" {
-			cookie.Value = ""
-		}
-		c.options.SetCookies[i] = cookie
+		cookies = append(cookies, cookie)
 	}
+	cookies = mergeCookies(cookies, restored)
 
-	if len(c.options.SetCookies) > 0 {
-		protoCookies := make([]*proto.NetworkCookieParam, len(c.options.SetCookies))
-		for i, cookie := range c.options.SetCookies {
+	if len(cookies) > 0 {
+		protoCookies := make([]*proto.NetworkCookieParam, len(cookies))
+		for i, cookie := range cookies {
 			protoCookies[i] = &proto.NetworkCookieParam{
 				Name:     cookie.Name,
 				Value:    cookie.Value,
 				Domain:   cookie.Domain,
 				Path:     cookie.Path,
-				Expires:  proto.TimeSinceEpoch(cookie.Expires),
 				Secure:   cookie.Secure,
 				HTTPOnly: cookie.HttpOnly,
 			}
+			if cookie.Expires > 0 {
+				protoCookies[i].Expires = proto.TimeSinceEpoch(cookie.Expires)
+			}
 		}
 		if err := c.page.SetCookies(protoCookies); err != nil {
 			return err
@@ -560,19 +844,63 @@ func (c *Crawler) setupHeadersAndCookies() error {
 	return nil
 }
 
-func (c *Crawler) startMutationObserver() error {
-	_, err := c.page.Eval(`
+func (c *Crawler) ExcludeElements(rules ...ElementExclusion) error {
+	c.options.ExcludedElements = append(c.options.ExcludedElements, rules...)
+
+	rulesJSON, err := json.Marshal(c.options.ExcludedElements)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.page.Eval(fmt.Sprintf(`() => {
+		if (window.__PROBE__) {
+			window.__PROBE__.options.excludedElements = %s;
+		}
+	}`, string(rulesJSON))); err != nil {
+		return err
+	}
+
+	return c.applyExclusions(c.page)
+}
+
+func (c *Crawler) applyExclusions(page *rod.Page) error {
+	if len(c.options.ExcludedElements) == 0 {
+		return nil
+	}
+
+	js := `
+		() => {
+			if (window.__PROBE__) {
+				window.__PROBE__.applyExclusions(document.documentElement);
+			}
+		}
+	`
+	return c.evalPage(page, js)
+}
+
+func (c *Crawler) startMutationObserver(page *rod.Page) error {
+	js := `
 		() => {
 			if (window.__PROBE__) {
 				window.__PROBE__._newMutationObserver(document.documentElement);
 			}
 		}
-	`)
-	return err
+	`
+	return c.evalPage(page, js)
+}
+
+func (c *Crawler) evalPage(page *rod.Page, js string) error {
+	if _, err := page.Eval(js); err != nil {
+		return err
+	}
+	if page == c.page {
+		c.evalFrames(js)
+	}
+	return nil
 }
 
 func (c *Crawler) resetMutationObserver() error {
-	_, err := c.page.Eval(`
+	js := `
 		() => {
 			if (window.__PROBE__) {
 				window.__PROBE__.DOMMutations = [];
@@ -580,34 +908,49 @@ func (c *Crawler) resetMutationObserver() error {
 				window.__PROBE__.totalDOMMutations = 0;
 			}
 		}
-	`)
-	return err
+	`
+	if _, err := c.page.Eval(js); err != nil {
+		return err
+	}
+	c.evalFrames(js)
+	return nil
 }
 
 func (c *Crawler) fillInputValues(element *rod.Element) error {
-	selector := "html"
-	if element != nil {
-		selector = element.MustText()
-	}
-
-	_, err := c.page.Eval(fmt.Sprintf(`
-		() => {
-			if (window.__PROBE__) {
-				return window.__PROBE__.fillInputValues(%s);
+	if element == nil {
+		js := `
+			() => {
+				if (window.__PROBE__) {
+					return window.__PROBE__.fillInputValues(document.documentElement);
+				}
 			}
+		`
+		if _, err := c.page.Eval(js); err != nil {
+			return err
 		}
-	`, selector))
+		c.evalFrames(js)
+		return nil
+	}
 
+	_, err := element.Eval(`function() {
+		if (window.__PROBE__) {
+			return window.__PROBE__.fillInputValues(this);
+		}
+	}`)
 	return err
 }
 
 func (c *Crawler) crawlDOM(element *rod.Element) error {
-	elements, err := c.getDOMTreeAsArray(element)
+	elements, err := c.crawlTargets(element)
 	if err != nil {
 		return err
 	}
 
-	for _, el := range elements {
+	c.mu.RLock()
+	relogins := c.relogins
+	c.mu.RUnlock()
+
+	for i := 0; i < len(elements); i++ {
 		c.mu.RLock()
 		stop := c.stop
 		c.mu.RUnlock()
@@ -616,14 +959,52 @@ func (c *Crawler) crawlDOM(element *rod.Element) error {
 			break
 		}
 
-		if err := c.crawlElement(el); err != nil {
+		c.crawlElement(elements[i])
+
+		c.mu.RLock()
+		n, trigger := c.relogins, c.trigger
+		c.mu.RUnlock()
+		if n == relogins {
 			continue
 		}
+
+		relogins = n
+		if elements, err = c.crawlTargets(element); err != nil {
+			return err
+		}
+		i = c.resumeIndex(elements, trigger, i)
 	}
 
 	return nil
 }
 
+func (c *Crawler) crawlTargets(element *rod.Element) ([]*rod.Element, error) {
+	elements, err := c.getDOMTreeAsArray(element)
+	if err != nil {
+		return nil, err
+	}
+
+	if element == nil {
+		for _, f := range c.childFrames() {
+			if frameElements, err := c.frameDOMTree(f); err == nil {
+				elements = append(elements, frameElements...)
+			}
+		}
+	}
+	return elements, nil
+}
+
+func (c *Crawler) resumeIndex(elements []*rod.Element, trigger *Trigger, i int) int {
+	if trigger != nil {
+		for j, el := range elements {
+			if selector, err := c.GetElementSelector(el); err == nil && selector == trigger.Element {
+				return j
+			}
+		}
+	}
+	return i
+}
+
 func (c *Crawler) crawlElement(element *rod.Element) error {
 	events, err := c.getEventsForElement(element)
 	if err != nil {
@@ -648,61 +1029,114 @@ func (c *Crawler) crawlElement(element *rod.Element) error {
 }
 
 func (c *Crawler) getDOMTreeAsArray(node *rod.Element) ([]*rod.Element, error) {
-	var out []*rod.Element
-
-	selector := "html"
-	if node != nil {
-		selector = node.MustText()
+	if node == nil {
+		return c.page.ElementsByJS(rod.Eval(`() => window.__PROBE__ ? window.__PROBE__.getDOMTree(document.documentElement) : []`))
 	}
+	return node.ElementsByJS(rod.Eval(`function() {
+		return window.__PROBE__ ? window.__PROBE__.getDOMTree(this) : [];
+	}`))
+}
 
-	elements, err := c.page.Elements(selector + " > *:not([data-htcrawl_crawl_excluded_element])")
+func (c *Crawler) frameDOMTree(f *childFrame) ([]*rod.Element, error) {
+	return f.page.ElementsByJS(rod.Eval(`() => window.__PROBE__ ? window.__PROBE__.getDOMTree(document.documentElement) : []`))
+}
+
+func (c *Crawler) getEventsForElement(el *rod.Element) ([]string, error) {
+	var events []string
+
+	res, err := el.Eval(`function() {
+		if (window.__PROBE__) {
+			return window.__PROBE__.getEventsForElement(this);
+		}
+		return [];
+	}`)
 	if err != nil {
-		return out, err
+		return events, err
 	}
 
-	for _, el := range elements {
-		out = append(out, el)
-		children, err := c.getDOMTreeAsArray(el)
-		if err != nil {
-			continue
-		}
-		out = append(out, children...)
+	for _, ev := range res.Value.Arr() {
+		events = append(events, ev.Str())
 	}
 
-	return out, nil
+	return events, nil
 }
 
-func (c *Crawler) getEventsForElement(el *rod.Element) ([]string, error) {
-	var events []string
+func (c *Crawler) triggerElementEvent(el *rod.Element, event string) error {
+	page := c.pageOf(el)
+	selector, _ := c.GetElementSelector(el)
+	trigger := c.newTrigger(el, selector, event)
+	c.SetTrigger(trigger)
 
-	selector := el.MustText()
+	var pageURL string
+	if info, err := c.page.Info(); err == nil {
+		pageURL = info.URL
+	}
 
-	_, err := c.page.Eval(fmt.Sprintf(`
-		() => {
-			if (window.__PROBE__) {
-				return window.__PROBE__.getEventsForElement(%s);
-			}
-			return [];
+	if err := c.dispatchElementEvent(el, event); err != nil {
+		return err
+	}
+
+	c.waitForPageRequests(page)
+
+	retry := func() error {
+		el, err := c.findElementIn(page, selector)
+		if err != nil {
+			return nil
 		}
-	`, selector))
+		if err := c.dispatchElementEvent(el, event); err != nil {
+			return err
+		}
+		c.waitForPageRequests(page)
+		return nil
+	}
+	if err := c.checkSession(trigger, pageURL, retry); err != nil {
+		c.mu.Lock()
+		c.errors = append(c.errors, [2]string{"session", err.Error()})
+		c.mu.Unlock()
+		return err
+	}
 
-	return events, err
-}
+	c.recordState(page, trigger)
+	c.crawlPopups()
 
-func (c *Crawler) triggerElementEvent(el *rod.Element, event string) error {
-	selector := el.MustText()
+	return nil
+}
 
-	_, err := c.page.Eval(fmt.Sprintf(`
-		() => {
-			if (window.__PROBE__) {
-				window.__PROBE__.triggerElementEvent(%s, "%s");
-			}
+func (c *Crawler) dispatchElementEvent(el *rod.Element, event string) error {
+	_, err := el.Eval(`function(event) {
+		if (window.__PROBE__) {
+			return window.__PROBE__.triggerElementEvent(this, event);
 		}
-	`, selector, event))
-
+	}`, event)
 	return err
 }
 
+func (c *Crawler) recordState(page *rod.Page, trigger *Trigger) *Snapshot {
+	res, err := page.Eval(`() => ({
+		url: document.location.href,
+		dom: Array.from(document.querySelectorAll("*")).map(e => e.tagName + " " + (e.getAttribute("class") || "").replace(/\s+/g, "")),
+		mutations: window.__PROBE__ ? window.__PROBE__.totalDOMMutations : 0
+	})`)
+	if err != nil {
+		return nil
+	}
+
+	domArray := make([]string, 0)
+	for _, e := range res.Value.Get("dom").Arr() {
+		domArray = append(domArray, e.Str())
+	}
+
+	node := c.stateGraph.Observe(res.Value.Get("url").Str(), domArray, res.Value.Get("mutations").Int(), trigger)
+	if c.options.Snapshots && node.SeenCount == 1 {
+		return c.captureState(page, node, trigger)
+	}
+	return nil
+}
+
+func (c *Crawler) StateGraph() *StateGraph {
+	return c.stateGraph
+}
+
 func (c *Crawler) Navigate(url string) error {
 	if !c.loaded {
 		return fmt.Errorf("crawler must be loaded before navigate")
@@ -850,22 +1284,29 @@ func (c *Crawler) GetElementText(el *rod.Element) (string, error) {
 }
 
 func (c *Crawler) GetElementSelector(el *rod.Element) (string, error) {
-	selector := el.MustText()
-
-	_, err := c.page.Eval(fmt.Sprintf(`
-		() => {
-			if (window.__PROBE__) {
-				return window.__PROBE__.getElementSelector(%s);
-			}
-			return "";
+	res, err := el.Eval(`function() {
+		if (window.__PROBE__) {
+			return window.__PROBE__.getElementSelector(this);
 		}
-	`, selector))
+		return "";
+	}`)
+	if err != nil {
+		return "", err
+	}
 
-	return "", err
+	selector := res.Value.Str()
+	if f := c.frameOf(el.Page()); f != nil && selector != "" {
+		path, err := c.framePath(f)
+		if err != nil {
+			return selector, nil
+		}
+		return "inframe/" + path + " ; " + strings.TrimPrefix(selector, "inframe/"), nil
+	}
+	return selector, nil
 }
 
 func (c *Crawler) GetTotalDomMutations() (int, error) {
-	_, err := c.page.Eval(`
+	res, err := c.page.Eval(`
 		() => {
 			if (window.__PROBE__) {
 				return window.__PROBE__.totalDOMMutations;
@@ -873,8 +1314,11 @@ func (c *Crawler) GetTotalDomMutations() (int, error) {
 			return 0;
 		}
 	`)
+	if err != nil {
+		return 0, err
+	}
 
-	return 0, err
+	return res.Value.Int(), nil
 }
 
 func (c *Crawler) PopMutation() (string, error) {
@@ -890,6 +1334,14 @@ func (c *Crawler) PopMutation() (string, error) {
 	return "", err
 }
 
+func (c *Crawler) newTrigger(el *rod.Element, selector, event string) *Trigger {
+	trigger := &Trigger{Element: selector, Event: event}
+	if c.options.TriggerScreenshots {
+		c.captureTrigger(el, trigger)
+	}
+	return trigger
+}
+
 func (c *Crawler) SetTrigger(trigger *Trigger) {
 	c.mu.Lock()
 	c.trigger = trigger
@@ -86,6 +86,7 @@ type AddNodeResult struct {
 	LastSeenAt      int64
 	SeenCount       int
 	TotDomMutations int
+	Node            *DOMNode
 }
 
 func (dd *DOMDeduplicator) AddNode(domArray []string, totDomMutations int) *AddNodeResult {
@@ -96,6 +97,7 @@ func (dd *DOMDeduplicator) AddNode(domArray []string, totDomMutations int) *AddN
 		dd.domNodes = append(dd.domNodes, newNode)
 		return &AddNodeResult{
 			Added: true,
+			Node:  newNode,
 		}
 	}
 
@@ -107,6 +109,7 @@ func (dd *DOMDeduplicator) AddNode(domArray []string, totDomMutations int) *AddN
 		LastSeenAt:      existingNode.LastSeenAt,
 		SeenCount:       existingNode.SeenCount,
 		TotDomMutations: existingNode.TotDomMutations,
+		Node:            existingNode,
 	}
 
 	return result
@@ -143,6 +143,18 @@ func (l *Logger) LogEvent(event *Event) {
 		if req, ok := event.Params["request"].(*Request); ok {
 			l.logger.Printf("[JSONP] %s", req.URL)
 		}
+	case "route":
+		if route, ok := event.Params["route"].(*Route); ok {
+			l.logger.Printf("[ROUTE] %s %s", route.Kind, route.URL)
+		}
+	case "snapshot":
+		if snapshot, ok := event.Params["snapshot"].(*Snapshot); ok {
+			l.logger.Printf("[SNAPSHOT] %s %s %s", snapshot.State, snapshot.URL, snapshot.Screenshot)
+		}
+	case "worker":
+		if worker, ok := event.Params["worker"].(*Worker); ok {
+			l.logger.Printf("[WORKER] %s %s", worker.Type, worker.URL)
+		}
 	case "postmessage":
 		if message, ok := event.Params["message"]; ok {
 			l.logger.Printf("[POSTMESSAGE] %v", message)
@@ -3,112 +3,43 @@ package main
 import (
 	"fmt"
 	"log"
-	"math/rand"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/seaung/htcrawl-go"
 )
 
-type PayloadMap struct {
-	Payload  string
-	Element  string
-}
-
 func main() {
 	targetURL := "https://example.com"
 
 	options := htcrawl.DefaultOptions()
 	options.Verbose = false
 	options.HeadlessChrome = true
+	options.CheckSinks = true
 
-	payloads := []string{
-		";window.___xssSink({0});",
-		"<img src='a' onerror=window.___xssSink({0})>",
-	}
-
-	for _, payload := range payloads {
-		crawlAndFuzz(targetURL, options, payload)
-	}
-}
-
-func crawlAndFuzz(targetURL string, options *htcrawl.Options, payload string) {
-	pmap := make(map[string]*PayloadMap)
-	hashSet := false
+	scan := htcrawl.DefaultXSSScanOptions()
+	scan.Payloads = append(scan.Payloads, htcrawl.XSSPayloadSets["dialog"]...)
 
-	crawler, err := htcrawl.Launch(targetURL, options)
+	scanner, err := htcrawl.NewXSSScanner(targetURL, options, scan)
 	if err != nil {
-		log.Printf("Failed to launch crawler: %v", err)
-		return
+		log.Fatalf("Failed to launch scanner: %v", err)
 	}
-	defer crawler.Close()
-
-	crawler.Page().Eval(`
-		() => {
-			window.___xssSink = function(key) {
-				console.log("XSS Sink called with key: " + key);
-				return true;
-			};
-		}
-	`)
-
-	crawler.On("fillinput", func(event *htcrawl.Event, crawler *htcrawl.Crawler) (interface{}, error) {
-		if element, ok := event.Params["element"].(string); ok {
-			p := getNewPayload(payload, element, pmap)
-			crawler.Page().Eval(fmt.Sprintf(`
-				() => {
-					var el = document.querySelector("%s");
-					if (el) {
-						el.value = "%s";
-					}
-				}
-			`, element, escapeJS(p)))
-			return false, nil
-		}
-		return nil, nil
-	})
+	defer scanner.Close()
 
-	crawler.On("triggerevent", func(event *htcrawl.Event, crawler *htcrawl.Crawler) (interface{}, error) {
-		if !hashSet {
-			p := getNewPayload(payload, "hash", pmap)
-			crawler.Page().Eval(fmt.Sprintf(`
-				() => {
-					document.location.hash = "%s";
-				}
-			`, escapeJS(p)))
-			hashSet = true
-		}
-		return nil, nil
+	scanner.OnFinding(func(f *htcrawl.XSSFinding) {
+		fmt.Printf("[%s] %s %s confirmed by %s\n", f.InjectionPoint, f.Parameter, f.Payload, f.Confirmation)
 	})
 
-	if err := crawler.Start(); err != nil {
-		log.Printf("Error during crawling: %v", err)
+	findings, err := scanner.Scan()
+	if err != nil {
+		log.Printf("Error during scanning: %v", err)
 	}
 
-	fmt.Println("Fuzzing completed!")
-}
-
-func getNewPayload(payload, element string, pmap map[string]*PayloadMap) string {
-	k := strconv.Itoa(rand.Intn(4000000000))
-	p := strings.ReplaceAll(payload, "{0}", k)
-	pmap[k] = &PayloadMap{
-		Payload: payload,
-		Element: element,
+	for _, f := range findings {
+		fmt.Printf("\n%s %s\n  %s\n", f.InjectionPoint, f.Parameter, strings.Join(f.Steps, "\n  "))
+		if f.Sink != nil {
+			fmt.Printf("  sink: %s\n%s\n", f.Sink.Sink, f.Sink.Stack)
+		}
 	}
-	return p
-}
-
-func escapeJS(s string) string {
-	s = strings.ReplaceAll(s, `\`, `\\`)
-	s = strings.ReplaceAll(s, `"`, `\"`)
-	s = strings.ReplaceAll(s, `'`, `\'`)
-	s = strings.ReplaceAll(s, "\n", `\n`)
-	s = strings.ReplaceAll(s, "\r", `\r`)
-	s = strings.ReplaceAll(s, "\t", `\t`)
-	return s
-}
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+	fmt.Printf("\nScan completed: %d findings\n", len(findings))
 }
@@ -0,0 +1,611 @@
+package htcrawl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type PostmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type PostmanQuery struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type PostmanURL struct {
+	Raw      string         `json:"raw"`
+	Protocol string         `json:"protocol,omitempty"`
+	Host     []string       `json:"host,omitempty"`
+	Port     string         `json:"port,omitempty"`
+	Path     []string       `json:"path,omitempty"`
+	Query    []PostmanQuery `json:"query,omitempty"`
+}
+
+type PostmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+type PostmanRequest struct {
+	Method string          `json:"method"`
+	Header []PostmanHeader `json:"header"`
+	URL    PostmanURL      `json:"url"`
+	Body   *PostmanBody    `json:"body,omitempty"`
+}
+
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Item    []*PostmanItem  `json:"item,omitempty"`
+	Request *PostmanRequest `json:"request,omitempty"`
+}
+
+type PostmanCollection struct {
+	Info PostmanInfo    `json:"info"`
+	Item []*PostmanItem `json:"item"`
+}
+
+func (pc *PostmanCollection) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(pc)
+}
+
+type Exporter struct {
+	ExtraHeaders map[string]string
+	Cookies      []Cookie
+}
+
+func (c *Crawler) Exporter() *Exporter {
+	cookies, _ := c.Cookies()
+	return &Exporter{
+		ExtraHeaders: c.options.ExtraHeaders,
+		Cookies:      cookies,
+	}
+}
+
+func (e *Exporter) Postman(name string, requests []*Request) *PostmanCollection {
+	collection := &PostmanCollection{
+		Info: PostmanInfo{Name: name, Schema: postmanSchema},
+		Item: make([]*PostmanItem, 0),
+	}
+
+	hosts := make(map[string]*PostmanItem)
+	paths := make(map[string]*PostmanItem)
+
+	for _, req := range exportableRequests(requests) {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			continue
+		}
+
+		hostItem, ok := hosts[u.Host]
+		if !ok {
+			hostItem = &PostmanItem{Name: u.Host}
+			hosts[u.Host] = hostItem
+			collection.Item = append(collection.Item, hostItem)
+		}
+
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		pathItem, ok := paths[u.Host+path]
+		if !ok {
+			pathItem = &PostmanItem{Name: path}
+			paths[u.Host+path] = pathItem
+			hostItem.Item = append(hostItem.Item, pathItem)
+		}
+
+		pr := &PostmanRequest{
+			Method: requestMethod(req),
+			Header: make([]PostmanHeader, 0),
+			URL: PostmanURL{
+				Raw:      req.URL,
+				Protocol: u.Scheme,
+				Host:     strings.Split(u.Hostname(), "."),
+				Port:     u.Port(),
+				Path:     strings.Split(strings.TrimPrefix(u.Path, "/"), "/"),
+			},
+		}
+		for _, kv := range strings.Split(u.RawQuery, "&") {
+			if kv == "" {
+				continue
+			}
+			parts := strings.SplitN(kv, "=", 2)
+			q := PostmanQuery{Key: parts[0]}
+			if len(parts) > 1 {
+				q.Value = parts[1]
+			}
+			pr.URL.Query = append(pr.URL.Query, q)
+		}
+		for _, h := range e.headersFor(req) {
+			pr.Header = append(pr.Header, PostmanHeader{Key: h[0], Value: h[1]})
+		}
+		if req.Data != "" {
+			pr.Body = &PostmanBody{Mode: "raw", Raw: req.Data}
+		}
+
+		pathItem.Item = append(pathItem.Item, &PostmanItem{
+			Name:    requestMethod(req) + " " + TruncateString(req.URL, 120),
+			Request: pr,
+		})
+	}
+
+	return collection
+}
+
+func (e *Exporter) Curl(w io.Writer, requests []*Request) error {
+	if _, err := fmt.Fprintln(w, "#!/bin/sh"); err != nil {
+		return err
+	}
+
+	for _, req := range exportableRequests(requests) {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("\n# [%s] %s %s\n", req.Type, requestMethod(req), req.URL))
+		if req.Trigger != nil {
+			sb.WriteString(fmt.Sprintf("# Trigger: %s on %s\n", req.Trigger.Event, req.Trigger.Element))
+		}
+		sb.WriteString("curl -sk -X " + requestMethod(req) + " " + shellQuote(req.URL))
+		for _, h := range e.headersFor(req) {
+			sb.WriteString(" \\\n  -H " + shellQuote(h[0]+": "+h[1]))
+		}
+		if req.Data != "" {
+			sb.WriteString(" \\\n  --data-raw " + shellQuote(req.Data))
+		}
+		sb.WriteString("\n")
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) headersFor(req *Request) [][2]string {
+	headers := MergeStringMaps(req.ExtraHeaders, e.ExtraHeaders)
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		if strings.EqualFold(k, "cookie") || strings.HasPrefix(k, ":") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([][2]string, 0, len(keys)+1)
+	for _, k := range keys {
+		result = append(result, [2]string{k, headers[k]})
+	}
+
+	cookies := make([]string, 0)
+	names := make(map[string]bool)
+	for _, pair := range strings.Split(headerValue(headers, "Cookie"), ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(pair, "=")
+		if !names[name] {
+			names[name] = true
+			cookies = append(cookies, pair)
+		}
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		for _, cookie := range e.Cookies {
+			if cookieMatchesURL(cookie, u) && !names[cookie.Name] {
+				names[cookie.Name] = true
+				cookies = append(cookies, cookie.Name+"="+cookie.Value)
+			}
+		}
+	}
+	if len(cookies) > 0 {
+		result = append(result, [2]string{"Cookie", strings.Join(cookies, "; ")})
+	}
+	return result
+}
+
+func cookieMatchesURL(cookie Cookie, u *url.URL) bool {
+	host := u.Hostname()
+	domain := strings.TrimPrefix(cookie.Domain, ".")
+	if domain != "" && host != domain && !strings.HasSuffix(host, "."+domain) {
+		return false
+	}
+	if cookie.Secure && u.Scheme != "https" {
+		return false
+	}
+	if cookie.Path != "" && !strings.HasPrefix(u.Path, cookie.Path) {
+		return u.Path == "" && cookie.Path == "/"
+	}
+	return true
+}
+
+func exportableRequests(requests []*Request) []*Request {
+	seen := make(map[string]bool)
+	result := make([]*Request, 0, len(requests))
+	for _, req := range requests {
+		if req == nil || req.Type == "websocket" {
+			continue
+		}
+		key := req.Method + req.URL + req.Data
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, req)
+	}
+	return result
+}
+
+func requestMethod(req *Request) string {
+	if req.Method == "" {
+		return "GET"
+	}
+	return strings.ToUpper(req.Method)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpData struct {
+	Base64 bool   `xml:"base64,attr"`
+	Data   string `xml:",cdata"`
+}
+
+type burpItem struct {
+	Time           string    `xml:"time"`
+	URL            string    `xml:"url"`
+	Host           burpHost  `xml:"host"`
+	Port           string    `xml:"port"`
+	Protocol       string    `xml:"protocol"`
+	Method         string    `xml:"method"`
+	Path           string    `xml:"path"`
+	Extension      string    `xml:"extension"`
+	Request        burpData  `xml:"request"`
+	Status         int       `xml:"status"`
+	ResponseLength int       `xml:"responselength"`
+	MimeType       string    `xml:"mimetype"`
+	Response       *burpData `xml:"response"`
+	Comment        string    `xml:"comment"`
+}
+
+type burpItems struct {
+	XMLName     xml.Name   `xml:"items"`
+	BurpVersion string     `xml:"burpVersion,attr"`
+	ExportTime  string     `xml:"exportTime,attr"`
+	Items       []burpItem `xml:"item"`
+}
+
+func (e *Exporter) BurpXML(w io.Writer, exchanges []*Exchange) error {
+	items := burpItems{
+		BurpVersion: "htcrawl-go",
+		ExportTime:  time.Now().Format(burpTimeFormat),
+		Items:       make([]burpItem, 0, len(exchanges)),
+	}
+
+	for _, ex := range exchanges {
+		if ex == nil || ex.Request == nil || ex.Request.Type == "websocket" {
+			continue
+		}
+		u, err := url.Parse(ex.Request.URL)
+		if err != nil {
+			continue
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		extension := "null"
+		if i := strings.LastIndex(u.Path, "."); i > strings.LastIndex(u.Path, "/") {
+			extension = u.Path[i+1:]
+		}
+
+		item := burpItem{
+			Time:      time.Unix(ex.Request.Timestamp/1000, 0).Format(burpTimeFormat),
+			URL:       ex.Request.URL,
+			Host:      burpHost{Name: u.Hostname()},
+			Port:      port,
+			Protocol:  u.Scheme,
+			Method:    requestMethod(ex.Request),
+			Path:      u.RequestURI(),
+			Extension: extension,
+			Request: burpData{
+				Base64: true,
+				Data:   base64.StdEncoding.EncodeToString(e.RawRequest(ex.Request)),
+			},
+		}
+
+		if ex.Response != nil {
+			raw := RawResponse(ex.Response)
+			item.Status = ex.Response.Status
+			item.ResponseLength = len(raw)
+			item.MimeType = burpMimeType(ex.Response.MimeType)
+			item.Response = &burpData{
+				Base64: true,
+				Data:   base64.StdEncoding.EncodeToString(raw),
+			}
+		}
+
+		items.Items = append(items.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(items); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+const burpTimeFormat = "Mon Jan 02 15:04:05 MST 2006"
+
+func burpMimeType(mimeType string) string {
+	switch {
+	case strings.Contains(mimeType, "json"):
+		return "JSON"
+	case strings.Contains(mimeType, "html"):
+		return "HTML"
+	case strings.Contains(mimeType, "javascript"):
+		return "script"
+	case strings.Contains(mimeType, "css"):
+		return "CSS"
+	case strings.Contains(mimeType, "xml"):
+		return "XML"
+	case strings.HasPrefix(mimeType, "image/"):
+		return strings.ToUpper(strings.TrimPrefix(mimeType, "image/"))
+	case strings.HasPrefix(mimeType, "text/"):
+		return "text"
+	}
+	return ""
+}
+
+func (e *Exporter) RawRequest(req *Request) []byte {
+	var sb strings.Builder
+	path := "/"
+	host := ""
+	if u, err := url.Parse(req.URL); err == nil {
+		path = u.RequestURI()
+		host = u.Host
+	}
+	sb.WriteString(requestMethod(req) + " " + path + " HTTP/1.1\r\n")
+	sb.WriteString("Host: " + host + "\r\n")
+	for _, h := range e.headersFor(req) {
+		if strings.EqualFold(h[0], "host") {
+			continue
+		}
+		sb.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	if req.Data != "" && headerValue(req.ExtraHeaders, "Content-Length") == "" {
+		sb.WriteString("Content-Length: " + strconv.Itoa(len(req.Data)) + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	sb.WriteString(req.Data)
+	return []byte(sb.String())
+}
+
+func RawResponse(resp *Response) []byte {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("HTTP/1.1 %d %s\r\n", resp.Status, resp.StatusText))
+	keys := make([]string, 0, len(resp.Headers))
+	for k := range resp.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range strings.Split(resp.Headers[k], "\n") {
+			sb.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	sb.WriteString("\r\n")
+	return append([]byte(sb.String()), responseBody(resp)...)
+}
+
+func responseBody(resp *Response) []byte {
+	if resp.Base64 {
+		body, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err == nil {
+			return body
+		}
+	}
+	return []byte(resp.Body)
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    int `json:"send"`
+	Wait    int `json:"wait"`
+	Receive int `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string                 `json:"startedDateTime"`
+	Time            int                    `json:"time"`
+	Request         harRequest             `json:"request"`
+	Response        harResponse            `json:"response"`
+	Cache           map[string]interface{} `json:"cache"`
+	Timings         harTimings             `json:"timings"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+func (e *Exporter) HAR(w io.Writer, exchanges []*Exchange) error {
+	log := harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "htcrawl-go", Version: "1.0"},
+		Entries: make([]harEntry, 0, len(exchanges)),
+	}
+
+	for _, ex := range exchanges {
+		if ex == nil || ex.Request == nil || ex.Request.Type == "websocket" {
+			continue
+		}
+		req := ex.Request
+
+		hr := harRequest{
+			Method:      requestMethod(req),
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     make([]harNameValue, 0),
+			QueryString: make([]harNameValue, 0),
+			Cookies:     make([]harNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    len(req.Data),
+		}
+		for _, h := range e.headersFor(req) {
+			hr.Headers = append(hr.Headers, harNameValue{Name: h[0], Value: h[1]})
+		}
+		if u, err := url.Parse(req.URL); err == nil {
+			for name, values := range u.Query() {
+				for _, v := range values {
+					hr.QueryString = append(hr.QueryString, harNameValue{Name: name, Value: v})
+				}
+			}
+			sort.Slice(hr.QueryString, func(i, j int) bool { return hr.QueryString[i].Name < hr.QueryString[j].Name })
+		}
+		if req.Data != "" {
+			mimeType := headerValue(req.ExtraHeaders, "Content-Type")
+			if mimeType == "" {
+				mimeType = "application/x-www-form-urlencoded"
+			}
+			hr.PostData = &harPostData{MimeType: mimeType, Text: req.Data}
+		}
+
+		hresp := harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     make([]harNameValue, 0),
+			Cookies:     make([]harNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    -1,
+			Content:     harContent{MimeType: "x-unknown"},
+		}
+		if resp := ex.Response; resp != nil {
+			body := responseBody(resp)
+			hresp.Status = resp.Status
+			hresp.StatusText = resp.StatusText
+			hresp.BodySize = len(body)
+			hresp.RedirectURL = headerValue(resp.Headers, "Location")
+			hresp.Content = harContent{
+				Size:     len(body),
+				MimeType: resp.MimeType,
+				Text:     base64.StdEncoding.EncodeToString(body),
+				Encoding: "base64",
+			}
+			keys := make([]string, 0, len(resp.Headers))
+			for k := range resp.Headers {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				hresp.Headers = append(hresp.Headers, harNameValue{Name: k, Value: resp.Headers[k]})
+			}
+		}
+
+		log.Entries = append(log.Entries, harEntry{
+			StartedDateTime: time.Unix(req.Timestamp/1000, (req.Timestamp%1000)*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano),
+			Request:         hr,
+			Response:        hresp,
+			Cache:           map[string]interface{}{},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"log": log})
+}
+
+func URLList(w io.Writer, exchanges []*Exchange) error {
+	seen := make(map[string]bool)
+	for _, ex := range exchanges {
+		if ex == nil || ex.Request == nil || seen[ex.Request.URL] {
+			continue
+		}
+		seen[ex.Request.URL] = true
+		if _, err := fmt.Fprintln(w, ex.Request.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
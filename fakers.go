@@ -0,0 +1,308 @@
+package htcrawl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type Locale struct {
+	Code           string
+	FirstNames     []string
+	Surnames       []string
+	Streets        []string
+	Cities         []string
+	Country        string
+	StreetFormat   string
+	PostcodeFormat string
+	PhoneFormat    string
+	IBANCountry    string
+	BBANFormat     string
+}
+
+var Locales = map[string]*Locale{
+	"en_US": {
+		Code:           "en_US",
+		FirstNames:     names,
+		Surnames:       surnames,
+		Streets:        []string{"Main Street", "Oak Avenue", "Maple Drive", "Cedar Lane", "Park Road", "Washington Street"},
+		Cities:         []string{"Springfield", "Portland", "Austin", "Denver", "Columbus", "Madison"},
+		Country:        "United States",
+		StreetFormat:   "%[2]d %[1]s",
+		PostcodeFormat: "#####",
+		PhoneFormat:    "+1 ### ### ####",
+		IBANCountry:    "DE",
+		BBANFormat:     "##################",
+	},
+	"en_GB": {
+		Code:           "en_GB",
+		FirstNames:     []string{"oliver", "george", "harry", "jack", "olivia", "amelia", "isla", "emily"},
+		Surnames:       []string{"smith", "jones", "taylor", "brown", "williams", "wilson", "evans", "thomas"},
+		Streets:        []string{"High Street", "Station Road", "Church Lane", "Victoria Road", "Green Lane"},
+		Cities:         []string{"London", "Manchester", "Bristol", "Leeds", "York", "Oxford"},
+		Country:        "United Kingdom",
+		StreetFormat:   "%[2]d %[1]s",
+		PostcodeFormat: "??# #??",
+		PhoneFormat:    "+44 7### ######",
+		IBANCountry:    "GB",
+		BBANFormat:     "????##############",
+	},
+	"it_IT": {
+		Code:           "it_IT",
+		FirstNames:     []string{"marco", "giuseppe", "luca", "andrea", "giulia", "francesca", "chiara", "sara"},
+		Surnames:       []string{"rossi", "russo", "ferrari", "esposito", "bianchi", "romano", "colombo", "ricci"},
+		Streets:        []string{"Via Roma", "Via Garibaldi", "Corso Italia", "Via Mazzini", "Via Dante"},
+		Cities:         []string{"Roma", "Milano", "Napoli", "Torino", "Bologna", "Firenze"},
+		Country:        "Italia",
+		StreetFormat:   "%[1]s %[2]d",
+		PostcodeFormat: "#####",
+		PhoneFormat:    "+39 3## ### ####",
+		IBANCountry:    "IT",
+		BBANFormat:     "?######################",
+	},
+	"de_DE": {
+		Code:           "de_DE",
+		FirstNames:     []string{"lukas", "leon", "finn", "jonas", "mia", "emma", "hannah", "sophia"},
+		Surnames:       []string{"mueller", "schmidt", "schneider", "fischer", "weber", "meyer", "wagner", "becker"},
+		Streets:        []string{"Hauptstrasse", "Schulstrasse", "Bahnhofstrasse", "Gartenweg", "Lindenallee"},
+		Cities:         []string{"Berlin", "Hamburg", "Muenchen", "Koeln", "Frankfurt", "Stuttgart"},
+		Country:        "Deutschland",
+		StreetFormat:   "%[1]s %[2]d",
+		PostcodeFormat: "#####",
+		PhoneFormat:    "+49 15# ########",
+		IBANCountry:    "DE",
+		BBANFormat:     "##################",
+	},
+	"fr_FR": {
+		Code:           "fr_FR",
+		FirstNames:     []string{"louis", "gabriel", "jules", "hugo", "emma", "jade", "louise", "alice"},
+		Surnames:       []string{"martin", "bernard", "dubois", "thomas", "robert", "richard", "petit", "durand"},
+		Streets:        []string{"Rue de la Paix", "Avenue Victor Hugo", "Rue du Moulin", "Boulevard Voltaire"},
+		Cities:         []string{"Paris", "Lyon", "Marseille", "Toulouse", "Nantes", "Lille"},
+		Country:        "France",
+		StreetFormat:   "%[2]d %[1]s",
+		PostcodeFormat: "#####",
+		PhoneFormat:    "+33 6 ## ## ## ##",
+		IBANCountry:    "FR",
+		BBANFormat:     "#######################",
+	},
+	"es_ES": {
+		Code:           "es_ES",
+		FirstNames:     []string{"hugo", "mateo", "martin", "lucas", "lucia", "sofia", "martina", "maria"},
+		Surnames:       []string{"garcia", "rodriguez", "gonzalez", "fernandez", "lopez", "martinez", "sanchez", "perez"},
+		Streets:        []string{"Calle Mayor", "Calle Real", "Avenida de la Constitucion", "Calle del Sol"},
+		Cities:         []string{"Madrid", "Barcelona", "Valencia", "Sevilla", "Zaragoza", "Malaga"},
+		Country:        "Espana",
+		StreetFormat:   "%[1]s %[2]d",
+		PostcodeFormat: "#####",
+		PhoneFormat:    "+34 6## ### ###",
+		IBANCountry:    "ES",
+		BBANFormat:     "####################",
+	},
+	"zh_CN": {
+		Code:           "zh_CN",
+		FirstNames:     []string{"wei", "fang", "na", "min", "jing", "lei", "qiang", "yan"},
+		Surnames:       []string{"wang", "li", "zhang", "liu", "chen", "yang", "huang", "zhao"},
+		Streets:        []string{"Renmin Road", "Jiefang Road", "Zhongshan Road", "Jianshe Road"},
+		Cities:         []string{"Beijing", "Shanghai", "Guangzhou", "Shenzhen", "Chengdu", "Hangzhou"},
+		Country:        "China",
+		StreetFormat:   "%[2]d %[1]s",
+		PostcodeFormat: "######",
+		PhoneFormat:    "+86 13# #### ####",
+		IBANCountry:    "DE",
+		BBANFormat:     "##################",
+	},
+}
+
+type Faker struct {
+	rg     *RandomGenerator
+	locale *Locale
+}
+
+func NewFaker(seed, locale string) *Faker {
+	l, ok := Locales[locale]
+	if !ok {
+		l = Locales["en_US"]
+	}
+	return &Faker{rg: NewRandomGenerator(seed), locale: l}
+}
+
+func (f *Faker) format(pattern string) string {
+	var sb strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '#':
+			sb.WriteByte(numbers[f.rg.rand(len(numbers))])
+		case '?':
+			sb.WriteByte(letters[26+f.rg.rand(26)])
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func (f *Faker) FirstName() string {
+	return f.rg.randArr(f.locale.FirstNames)
+}
+
+func (f *Faker) Surname() string {
+	return f.rg.randArr(f.locale.Surnames)
+}
+
+func (f *Faker) Street() string {
+	return fmt.Sprintf(f.locale.StreetFormat, f.rg.randArr(f.locale.Streets), 1+f.rg.rand(199))
+}
+
+func (f *Faker) City() string {
+	return f.rg.randArr(f.locale.Cities)
+}
+
+func (f *Faker) Postcode() string {
+	return f.format(f.locale.PostcodeFormat)
+}
+
+func (f *Faker) Phone() string {
+	return f.format(f.locale.PhoneFormat)
+}
+
+func (f *Faker) CardNumber() string {
+	digits := "4" + f.rg.randDigits(14)
+	return digits + string(rune('0'+luhnCheckDigit(digits)))
+}
+
+func (f *Faker) CardExpiry() string {
+	return fmt.Sprintf("%02d/%02d", 1+f.rg.rand(12), (time.Now().Year()+2+f.rg.rand(4))%100)
+}
+
+func (f *Faker) IBAN() string {
+	bban := f.format(f.locale.BBANFormat)
+	return f.locale.IBANCountry + ibanCheckDigits(f.locale.IBANCountry, bban) + bban
+}
+
+func (f *Faker) UUID() string {
+	b := make([]byte, 16)
+	for i := range b {
+		b[i] = byte(f.rg.rand(256))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (f *Faker) Password() string {
+	chars := []byte(strings.ToLower(f.rg.randString(5)) + strings.ToUpper(f.rg.randString(3)) + f.rg.randDigits(3) + string(symbols[f.rg.rand(len(symbols))]))
+	for i := len(chars) - 1; i > 0; i-- {
+		j := f.rg.rand(i + 1)
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
+
+func (f *Faker) Values() map[string]string {
+	rg := f.rg
+	values := make(map[string]string)
+
+	values["string"] = rg.randString(8)
+	values["number"] = rg.randDigits(3)
+	values["month"] = rg.randArr(months)
+	values["year"] = rg.randArr(years)
+	values["date"] = rg.randArr(years) + "-" + rg.randArr(months) + "-" + fmt.Sprintf("%02d", 1+rg.rand(28))
+	values["color"] = fmt.Sprintf("#%06x", rg.rand(0x1000000))
+	values["week"] = rg.randArr(years) + "-W" + fmt.Sprintf("%02d", 1+rg.rand(52))
+	values["time"] = fmt.Sprintf("%02d:%02d", rg.rand(24), rg.rand(60))
+	values["datetimeLocal"] = values["date"] + "T" + values["time"]
+	values["domain"] = strings.ToLower(rg.randString(12)) + rg.randArr(domains)
+	values["firstname"] = f.FirstName()
+	values["surname"] = f.Surname()
+	values["lastname"] = values["surname"]
+	values["fullname"] = capitalize(values["firstname"]) + " " + capitalize(values["surname"])
+	values["username"] = values["firstname"] + "." + values["surname"] + rg.randDigits(2)
+	values["email"] = values["firstname"] + "." + values["surname"] + "@" + values["domain"]
+	values["url"] = "http://www." + values["domain"]
+	values["humandate"] = fmt.Sprintf("%02d/%02d/%s", 1+rg.rand(12), 1+rg.rand(28), rg.randArr(years))
+	values["password"] = f.Password()
+	values["tel"] = strings.NewReplacer(" ", "").Replace(f.Phone())
+	values["phone"] = f.Phone()
+	values["street"] = f.Street()
+	values["address"] = values["street"]
+	values["city"] = f.City()
+	values["postcode"] = f.Postcode()
+	values["country"] = f.locale.Country
+	values["cardnumber"] = f.CardNumber()
+	values["cardexpiry"] = f.CardExpiry()
+	values["cvv"] = rg.randDigits(3)
+	values["iban"] = f.IBAN()
+	values["uuid"] = f.UUID()
+
+	return values
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func luhnCheckDigit(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+func ValidLuhn(number string) bool {
+	number = strings.ReplaceAll(number, " ", "")
+	if len(number) < 2 {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return luhnCheckDigit(number[:len(number)-1]) == int(number[len(number)-1]-'0')
+}
+
+func ibanMod97(s string) int {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			sb.WriteString(fmt.Sprintf("%d", r-'A'+10))
+		default:
+			return -1
+		}
+	}
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return -1
+	}
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
+
+func ibanCheckDigits(country, bban string) string {
+	return fmt.Sprintf("%02d", 98-ibanMod97(bban+country+"00"))
+}
+
+func ValidIBAN(iban string) bool {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if len(iban) < 15 {
+		return false
+	}
+	return ibanMod97(iban[4:]+iban[:4]) == 1
+}
@@ -0,0 +1,335 @@
+package htcrawl
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+type InputField struct {
+	Selector     string   `json:"selector"`
+	Tag          string   `json:"tag"`
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	ID           string   `json:"id"`
+	Value        string   `json:"value"`
+	Checked      bool     `json:"checked"`
+	Label        string   `json:"label"`
+	Placeholder  string   `json:"placeholder"`
+	AriaLabel    string   `json:"ariaLabel"`
+	Autocomplete string   `json:"autocomplete"`
+	Pattern      string   `json:"pattern"`
+	Min          string   `json:"min"`
+	Max          string   `json:"max"`
+	Step         string   `json:"step"`
+	MinLength    int      `json:"minLength"`
+	MaxLength    int      `json:"maxLength"`
+	Required     bool     `json:"required"`
+	Options      []string `json:"options"`
+	Accept       string   `json:"accept"`
+	Form         string   `json:"form"`
+	FormAction   string   `json:"formAction"`
+	FormMethod   string   `json:"formMethod"`
+	Attempt      int      `json:"attempt"`
+}
+
+var autocompleteValueTypes = map[string]string{
+	"email":            "email",
+	"tel":              "tel",
+	"tel-national":     "tel",
+	"tel-local":        "tel",
+	"url":              "url",
+	"name":             "fullname",
+	"given-name":       "firstname",
+	"additional-name":  "firstname",
+	"nickname":         "firstname",
+	"family-name":      "surname",
+	"username":         "username",
+	"new-password":     "password",
+	"current-password": "password",
+	"bday":             "humandate",
+	"bday-day":         "number",
+	"bday-month":       "month",
+	"bday-year":        "year",
+	"cc-exp-month":     "month",
+	"cc-exp-year":      "year",
+	"postal-code":      "postcode",
+	"street-address":   "address",
+	"address-line1":    "address",
+	"address-level2":   "city",
+	"country-name":     "country",
+	"cc-name":          "fullname",
+	"cc-number":        "cardnumber",
+	"cc-csc":           "cvv",
+	"cc-exp":           "cardexpiry",
+	"tel-country-code": "number",
+}
+
+var inputTypeValueTypes = map[string]string{
+	"email":          "email",
+	"url":            "url",
+	"tel":            "tel",
+	"password":       "password",
+	"color":          "color",
+	"date":           "date",
+	"month":          "month",
+	"week":           "week",
+	"time":           "time",
+	"datetime-local": "datetimeLocal",
+	"number":         "number",
+	"range":          "number",
+}
+
+func (f *InputField) Hints() []string {
+	hints := make([]string, 0, 5)
+	for _, h := range []string{f.Name, f.ID, f.Label, f.Placeholder, f.AriaLabel} {
+		if h = strings.TrimSpace(h); h != "" {
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+func ValueTypeForField(f *InputField, matches []InputMatch) string {
+	for _, token := range strings.Fields(strings.ToLower(f.Autocomplete)) {
+		if t, ok := autocompleteValueTypes[token]; ok {
+			if t == "humandate" && f.Type == "date" {
+				t = "date"
+			}
+			return t
+		}
+	}
+
+	if t, ok := inputTypeValueTypes[f.Type]; ok {
+		return t
+	}
+
+	for _, hint := range f.Hints() {
+		for _, match := range matches {
+			if matched, err := regexp.MatchString("(?i)"+match.Name, hint); err == nil && matched {
+				return match.Value
+			}
+		}
+	}
+
+	return "string"
+}
+
+func GenerateFieldValue(f *InputField, matches []InputMatch, values map[string]string) string {
+	if f.Tag == "select" {
+		return selectOption(f)
+	}
+
+	valueType := ValueTypeForField(f, matches)
+	value, ok := values[valueType]
+	if !ok {
+		value = values["string"]
+	}
+
+	switch f.Type {
+	case "month":
+		value = values["year"] + "-" + values["month"]
+	case "color":
+		if !regexp.MustCompile(`^#[0-9a-fA-F]{6}$`).MatchString(value) {
+			value = fmt.Sprintf("#%06x", CRC32(value)&0xffffff)
+		}
+	}
+
+	return ConstrainValue(f, value)
+}
+
+func selectOption(f *InputField) string {
+	options := make([]string, 0, len(f.Options))
+	for _, o := range f.Options {
+		if o != "" || !f.Required {
+			options = append(options, o)
+		}
+	}
+	if len(options) == 0 {
+		return ""
+	}
+	return options[len(options)-1-f.Attempt%len(options)]
+}
+
+func ConstrainValue(f *InputField, value string) string {
+	switch f.Type {
+	case "number", "range":
+		return constrainNumber(f, value)
+	case "date", "month", "week", "time", "datetime-local":
+		if f.Min != "" && value < f.Min {
+			value = f.Min
+		}
+		if f.Max != "" && value > f.Max {
+			value = f.Max
+		}
+		return value
+	case "checkbox", "radio", "color":
+		return value
+	}
+
+	var pattern *regexp.Regexp
+	if f.Pattern != "" {
+		pattern, _ = regexp.Compile("^(?:" + f.Pattern + ")$")
+	}
+
+	value = constrainLength(f, value)
+	if pattern != nil && !pattern.MatchString(value) {
+		if generated, ok := GenerateFromPattern(f.Pattern, f.Attempt); ok {
+			value = generated
+			if adjusted := constrainLength(f, value); pattern.MatchString(adjusted) {
+				value = adjusted
+			}
+		}
+	}
+	return value
+}
+
+func constrainLength(f *InputField, value string) string {
+	runes := []rune(value)
+	if f.MinLength > 0 && len(runes) < f.MinLength {
+		orig := runes
+		if len(orig) == 0 {
+			orig = []rune("a")
+		}
+		for i := len(runes); i < f.MinLength; i++ {
+			runes = append(runes, orig[i%len(orig)])
+		}
+	}
+	if f.MaxLength > 0 && len(runes) > f.MaxLength {
+		runes = runes[:f.MaxLength]
+	}
+	return string(runes)
+}
+
+func constrainNumber(f *InputField, value string) string {
+	parse := func(s string, def float64) (float64, bool) {
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return def, false
+		}
+		return n, true
+	}
+
+	min, hasMin := parse(f.Min, 0)
+	max, hasMax := parse(f.Max, 0)
+	if f.Type == "range" {
+		if !hasMin {
+			min, hasMin = 0, true
+		}
+		if !hasMax {
+			max, hasMax = 100, true
+		}
+	}
+
+	step, hasStep := parse(f.Step, 1)
+	if !hasStep || step <= 0 {
+		step = 1
+	}
+	anyStep := strings.EqualFold(strings.TrimSpace(f.Step), "any")
+
+	n, ok := parse(value, 0)
+	if !ok {
+		n = float64(CRC32(value) % 1000)
+	}
+	n += float64(f.Attempt) * step
+
+	if hasMin && n < min {
+		n = min
+	}
+	if hasMax && n > max {
+		n = max
+	}
+
+	if !anyStep {
+		base := 0.0
+		if hasMin {
+			base = min
+		}
+		n = base + math.Floor((n-base)/step)*step
+		if hasMin && n < min {
+			n = min
+		}
+	}
+
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+const patternRunes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func GenerateFromPattern(pattern string, variant int) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var sb strings.Builder
+	generatePattern(&sb, re.Simplify(), variant)
+
+	full, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil || !full.MatchString(sb.String()) {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func generatePattern(sb *strings.Builder, re *syntax.Regexp, variant int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		sb.WriteRune(pickRune(re.Rune, variant))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteRune(rune(patternRunes[variant%len(patternRunes)]))
+	case syntax.OpCapture:
+		generatePattern(sb, re.Sub[0], variant)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generatePattern(sb, sub, variant)
+		}
+	case syntax.OpAlternate:
+		generatePattern(sb, re.Sub[variant%len(re.Sub)], variant)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		generatePattern(sb, re.Sub[0], variant)
+	case syntax.OpRepeat:
+		n := re.Min
+		if n == 0 && re.Max != 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			generatePattern(sb, re.Sub[0], variant)
+		}
+	}
+}
+
+func pickRune(ranges []rune, variant int) rune {
+	in := func(r rune) bool {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if r >= ranges[i] && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < len(patternRunes); i++ {
+		r := rune(patternRunes[(i+variant)%len(patternRunes)])
+		if in(r) {
+			return r
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1] >= 0x21 {
+			if ranges[i] < 0x21 {
+				return 0x21
+			}
+			return ranges[i]
+		}
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'a'
+}
@@ -0,0 +1,261 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+type bridgeHandler func(page *rod.Page, ctx proto.RuntimeExecutionContextID, payload gson.JSON) (interface{}, error)
+
+const bridgeBindingPrefix = "__htcrawl_binding"
+
+const bridgeScript = `(function() {
+	if (window.__htcrawl_resolve__) return;
+	var pending = {};
+	var lastId = 0;
+	window.__htcrawl_resolve__ = function(id, res, err) {
+		var p = pending[id];
+		delete pending[id];
+		if (!p) return;
+		if (err) {
+			p.reject(new Error(err));
+		} else {
+			p.resolve(res);
+		}
+	};
+	%s.forEach(function(name) {
+		var binding = window["%s" + name];
+		window[name] = function(req) {
+			return new Promise(function(resolve, reject) {
+				var id = ++lastId;
+				pending[id] = { resolve: resolve, reject: reject };
+				(binding || window["%s" + name])(JSON.stringify({ id: id, req: req === undefined ? null : req }));
+			});
+		};
+	});
+})();`
+
+type childFrame struct {
+	page    *rod.Page
+	parent  *rod.Page
+	session proto.TargetSessionID
+	target  proto.TargetTargetID
+	owner   string
+}
+
+func (c *Crawler) bridgeHandlers() map[string]bridgeHandler {
+	return map[string]bridgeHandler{
+		"__htcrawl_go_bridge__":   c.handleBridgeCall,
+		"__htcrawl_input_value__": c.handleInputValue,
+		"__htcrawl_set_files__":   c.handleSetFiles,
+	}
+}
+
+func (c *Crawler) exposeBridge(page *rod.Page) (string, error) {
+	handlers := c.bridgeHandlers()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		if err := (proto.RuntimeAddBinding{Name: bridgeBindingPrefix + name}).Call(page); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+
+	go page.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		handler, ok := handlers[strings.TrimPrefix(e.Name, bridgeBindingPrefix)]
+		if !ok || !strings.HasPrefix(e.Name, bridgeBindingPrefix) {
+			return
+		}
+		payload := gson.NewFrom(e.Payload)
+		res, err := handler(page, e.ExecutionContextID, payload.Get("req"))
+
+		result, jsonErr := json.Marshal(res)
+		if jsonErr != nil {
+			result = []byte("null")
+		}
+		message := []byte("null")
+		if err != nil {
+			message, _ = json.Marshal(err.Error())
+		}
+		_, _ = proto.RuntimeEvaluate{
+			Expression: fmt.Sprintf("window.__htcrawl_resolve__ && window.__htcrawl_resolve__(%d, %s, %s)", payload.Get("id").Int(), result, message),
+			ContextID:  e.ExecutionContextID,
+		}.Call(page)
+	})()
+
+	namesJSON, err := json.Marshal(names)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(bridgeScript, namesJSON, bridgeBindingPrefix, bridgeBindingPrefix), nil
+}
+
+func (c *Crawler) watchFrames(page *rod.Page) error {
+	err := proto.TargetSetAutoAttach{AutoAttach: true, WaitForDebuggerOnStart: true, Flatten: true}.Call(page)
+	if err != nil {
+		return err
+	}
+
+	go page.EachEvent(func(e *proto.TargetAttachedToTarget) {
+		if e.TargetInfo != nil && e.TargetInfo.Type == "iframe" {
+			if err := c.attachFrame(page, e); err != nil {
+				c.mu.Lock()
+				c.errors = append(c.errors, [2]string{"frame", fmt.Sprintf("%s: %v", e.TargetInfo.URL, err)})
+				c.mu.Unlock()
+			}
+		} else if e.TargetInfo != nil && isWorkerTarget(e.TargetInfo.Type) {
+			if err := c.attachWorker(c.browser.PageFromSession(e.SessionID), e.TargetInfo); err != nil {
+				c.mu.Lock()
+				c.errors = append(c.errors, [2]string{"worker", fmt.Sprintf("%s: %v", e.TargetInfo.URL, err)})
+				c.mu.Unlock()
+			}
+		}
+		if e.WaitingForDebugger {
+			_ = proto.RuntimeRunIfWaitingForDebugger{}.Call(c.browser.PageFromSession(e.SessionID))
+		}
+	}, func(e *proto.TargetDetachedFromTarget) {
+		c.detachFrame(e.SessionID)
+	})()
+	return nil
+}
+
+func (c *Crawler) attachFrame(parent *rod.Page, e *proto.TargetAttachedToTarget) error {
+	page, err := c.browser.PageFromTarget(e.TargetInfo.TargetID)
+	if err != nil {
+		return err
+	}
+
+	c.network.AttachFrame(page)
+	if c.options.BypassServiceWorker {
+		if err := (proto.NetworkSetBypassServiceWorker{Bypass: true}).Call(page); err != nil {
+			return err
+		}
+	}
+	if err := c.watchScripts(page); err != nil {
+		return err
+	}
+	if err := c.injectProbe(page, !e.WaitingForDebugger); err != nil {
+		return err
+	}
+	if err := c.watchFrames(page); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.frames = append(c.frames, &childFrame{
+		page:    page,
+		parent:  parent,
+		session: e.SessionID,
+		target:  e.TargetInfo.TargetID,
+	})
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Crawler) detachFrame(session proto.TargetSessionID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, f := range c.frames {
+		if f.session == session {
+			c.frames = append(c.frames[:i], c.frames[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Crawler) childFrames() []*childFrame {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	frames := make([]*childFrame, len(c.frames))
+	copy(frames, c.frames)
+	return frames
+}
+
+func (c *Crawler) frameOf(page *rod.Page) *childFrame {
+	if page == nil || page.SessionID == c.page.SessionID {
+		return nil
+	}
+	for _, f := range c.childFrames() {
+		if f.page.SessionID == page.SessionID {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *Crawler) framePath(f *childFrame) (string, error) {
+	c.mu.RLock()
+	owner := f.owner
+	c.mu.RUnlock()
+
+	if owner == "" {
+		res, err := proto.DOMGetFrameOwner{FrameID: proto.PageFrameID(f.target)}.Call(f.parent)
+		if err != nil {
+			return "", err
+		}
+		el, err := f.parent.ElementFromNode(&proto.DOMNode{BackendNodeID: res.BackendNodeID})
+		if err != nil {
+			return "", err
+		}
+		if owner, err = c.GetElementSelector(el); err != nil {
+			return "", err
+		}
+		c.mu.Lock()
+		f.owner = owner
+		c.mu.Unlock()
+	}
+	return strings.TrimPrefix(owner, "inframe/"), nil
+}
+
+func (c *Crawler) evalFrames(js string) {
+	for _, f := range c.childFrames() {
+		_, _ = f.page.Eval(js)
+	}
+}
+
+func (c *Crawler) pageOf(el *rod.Element) *rod.Page {
+	page := el.Page()
+	if page.SessionID == c.page.SessionID || c.frameOf(page) != nil {
+		return c.page
+	}
+	return page
+}
+
+func (c *Crawler) findElement(selector string) (*rod.Element, error) {
+	return c.findElementIn(c.page, selector)
+}
+
+func (c *Crawler) findElementIn(page *rod.Page, selector string) (*rod.Element, error) {
+	if page != c.page {
+		return page.Sleeper(rod.NotFoundSleeper).Element(selector)
+	}
+	page = page.Sleeper(rod.NotFoundSleeper)
+	if !strings.HasPrefix(selector, "inframe/") && !strings.Contains(selector, " >>> ") {
+		return page.Element(selector)
+	}
+
+	query := `(path) => window.__PROBE__ ? window.__PROBE__.querySelectorPath(path) : null`
+	if el, err := page.ElementByJS(rod.Eval(query, selector)); err == nil {
+		return el, nil
+	}
+
+	for _, f := range c.childFrames() {
+		path, err := c.framePath(f)
+		if err != nil || !strings.HasPrefix(selector, "inframe/"+path+" ; ") {
+			continue
+		}
+		rest := strings.TrimPrefix(selector, "inframe/"+path+" ; ")
+		if strings.Contains(rest, " ; ") {
+			rest = "inframe/" + rest
+		}
+		if el, err := f.page.Sleeper(rod.NotFoundSleeper).ElementByJS(rod.Eval(query, rest)); err == nil {
+			return el, nil
+		}
+	}
+	return nil, &rod.ErrElementNotFound{}
+}
@@ -2,10 +2,13 @@ module github.com/seaung/htcrawl-go
 
 go 1.21
 
-require github.com/go-rod/rod v0.112.0
+require (
+	github.com/go-rod/rod v0.112.0
+	github.com/ysmood/gson v0.7.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/ysmood/goob v0.4.0 // indirect
-	github.com/ysmood/gson v0.7.1 // indirect
 	github.com/ysmood/leakless v0.8.0 // indirect
 )
@@ -0,0 +1,318 @@
+package htcrawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+type MessageListener struct {
+	Frame       string `json:"frame"`
+	ID          int    `json:"id"`
+	Kind        string `json:"kind"`
+	Source      string `json:"source"`
+	OriginCheck string `json:"originCheck"`
+}
+
+func (l *MessageListener) ChecksOrigin() bool {
+	return l.OriginCheck != "" && l.OriginCheck != "none"
+}
+
+type ObservedMessage struct {
+	Frame  string      `json:"frame"`
+	Origin string      `json:"origin"`
+	Data   interface{} `json:"data"`
+}
+
+type MessageFuzzOptions struct {
+	Payloads    []string `json:"payloads" yaml:"payloads"`
+	Origin      string   `json:"origin" yaml:"origin"`
+	Wait        int      `json:"wait" yaml:"wait"`
+	MaxMessages int      `json:"maxMessages" yaml:"maxMessages"`
+}
+
+func DefaultMessageFuzzOptions() *MessageFuzzOptions {
+	return &MessageFuzzOptions{
+		Payloads:    DefaultXSSPayloads,
+		Origin:      "https://htcrawl.invalid",
+		Wait:        500,
+		MaxMessages: 50,
+	}
+}
+
+type MessageFuzzResult struct {
+	Frame     string             `json:"frame"`
+	Listeners []*MessageListener `json:"listeners"`
+	Origin    string             `json:"origin"`
+	Message   interface{}        `json:"message"`
+	Canary    string             `json:"canary"`
+	Sinks     []*Sink            `json:"sinks"`
+	Requests  []*Request         `json:"requests"`
+	Mutations int                `json:"mutations"`
+}
+
+func (r *MessageFuzzResult) Interesting() bool {
+	return len(r.Sinks) > 0 || len(r.Requests) > 0 || r.Mutations > 0
+}
+
+type messageFrame struct {
+	page      *rod.Page
+	url       string
+	listeners []*MessageListener
+	observed  []*ObservedMessage
+}
+
+var genericMessageKeys = []string{"type", "action", "message", "data", "url", "html"}
+
+func MessageShapes(observed []interface{}, payload string) []interface{} {
+	shapes := []interface{}{payload}
+	structured := false
+
+	for _, data := range observed {
+		if s, ok := data.(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+				switch parsed.(type) {
+				case map[string]interface{}, []interface{}:
+					structured = true
+					for _, v := range replaceStringLeaves(parsed, payload) {
+						if b, err := marshalMessage(v); err == nil {
+							shapes = append(shapes, b)
+						}
+					}
+				}
+			}
+			continue
+		}
+		switch data.(type) {
+		case map[string]interface{}, []interface{}:
+			structured = true
+			shapes = append(shapes, replaceStringLeaves(data, payload)...)
+		}
+	}
+
+	if !structured {
+		for _, k := range genericMessageKeys {
+			shapes = append(shapes, map[string]interface{}{k: payload})
+		}
+	}
+
+	result := make([]interface{}, 0, len(shapes))
+	seen := make(map[string]bool)
+	for _, s := range shapes {
+		b, _ := marshalMessage(s)
+		if !seen[b] {
+			seen[b] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func marshalMessage(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func replaceStringLeaves(data interface{}, payload string) []interface{} {
+	result := make([]interface{}, 0)
+	switch v := data.(type) {
+	case string:
+		result = append(result, payload)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, replaced := range replaceStringLeaves(v[k], payload) {
+				m := make(map[string]interface{}, len(v))
+				for mk, mv := range v {
+					m[mk] = mv
+				}
+				m[k] = replaced
+				result = append(result, m)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			for _, replaced := range replaceStringLeaves(v[i], payload) {
+				a := make([]interface{}, len(v))
+				copy(a, v)
+				a[i] = replaced
+				result = append(result, a)
+			}
+		}
+	}
+	return result
+}
+
+func (c *Crawler) messageFrames() ([]*messageFrame, error) {
+	if !c.options.CheckMessageListeners {
+		return nil, fmt.Errorf("checkMessageListeners option must be true to inspect message listeners")
+	}
+
+	pages := []*rod.Page{c.page}
+	for i := 0; i < len(pages) && i < 100; i++ {
+		elements, err := pages[i].Elements("iframe, frame")
+		if err != nil {
+			continue
+		}
+		for _, el := range elements {
+			if frame, err := el.Frame(); err == nil {
+				pages = append(pages, frame)
+			}
+		}
+	}
+	for _, f := range c.childFrames() {
+		pages = append(pages, f.page)
+	}
+
+	frames := make([]*messageFrame, 0, len(pages))
+	for _, p := range pages {
+		res, err := p.Eval(`() => window.__htcrawl_messages__ ? window.__htcrawl_messages__.snapshot() : null`)
+		if err != nil || res.Value.Nil() {
+			continue
+		}
+
+		frame := &messageFrame{page: p, url: res.Value.Get("url").Str()}
+		for _, l := range res.Value.Get("listeners").Arr() {
+			frame.listeners = append(frame.listeners, &MessageListener{
+				Frame:       frame.url,
+				ID:          l.Get("id").Int(),
+				Kind:        l.Get("kind").Str(),
+				Source:      l.Get("source").Str(),
+				OriginCheck: l.Get("originCheck").Str(),
+			})
+		}
+		for _, m := range res.Value.Get("observed").Arr() {
+			frame.observed = append(frame.observed, &ObservedMessage{
+				Frame:  frame.url,
+				Origin: m.Get("origin").Str(),
+				Data:   m.Get("data").Val(),
+			})
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+func (c *Crawler) MessageListeners() ([]*MessageListener, error) {
+	frames, err := c.messageFrames()
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]*MessageListener, 0)
+	for _, f := range frames {
+		listeners = append(listeners, f.listeners...)
+	}
+	return listeners, nil
+}
+
+func (c *Crawler) ObservedMessages() ([]*ObservedMessage, error) {
+	frames, err := c.messageFrames()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*ObservedMessage, 0)
+	for _, f := range frames {
+		messages = append(messages, f.observed...)
+	}
+	return messages, nil
+}
+
+func (c *Crawler) FuzzMessages(opts *MessageFuzzOptions) ([]*MessageFuzzResult, error) {
+	if opts == nil {
+		opts = DefaultMessageFuzzOptions()
+	}
+
+	frames, err := c.messageFrames()
+	if err != nil {
+		return nil, err
+	}
+
+	observed := make([]interface{}, 0)
+	for _, f := range frames {
+		for _, m := range f.observed {
+			observed = append(observed, m.Data)
+		}
+	}
+
+	results := make([]*MessageFuzzResult, 0)
+	seq := 0
+	for _, f := range frames {
+		if len(f.listeners) == 0 {
+			continue
+		}
+
+		sent := 0
+		for _, payload := range opts.Payloads {
+			seq++
+			canary := strconv.Itoa(800000000 + seq)
+			for _, message := range MessageShapes(observed, strings.ReplaceAll(payload, "{canary}", canary)) {
+				if opts.MaxMessages > 0 && sent >= opts.MaxMessages {
+					break
+				}
+				c.mu.RLock()
+				stop := c.stop
+				c.mu.RUnlock()
+				if stop {
+					return results, nil
+				}
+
+				result, err := c.sendMessage(f, message, opts)
+				if err != nil {
+					c.mu.Lock()
+					c.errors = append(c.errors, [2]string{"postmessage", err.Error()})
+					c.mu.Unlock()
+					continue
+				}
+				result.Canary = canary
+				results = append(results, result)
+				sent++
+			}
+		}
+	}
+	return results, nil
+}
+
+func (c *Crawler) sendMessage(f *messageFrame, message interface{}, opts *MessageFuzzOptions) (*MessageFuzzResult, error) {
+	sinksBefore := c.sinks.Count()
+	exchangesBefore := len(c.network.Exchanges())
+
+	res, err := f.page.Eval(`(data, origin, wait) => window.__htcrawl_messages__.send(data, origin, wait)`, message, opts.Origin, opts.Wait)
+	if err != nil {
+		return nil, err
+	}
+	c.waitForRequestsCompletion()
+
+	result := &MessageFuzzResult{
+		Frame:     f.url,
+		Listeners: f.listeners,
+		Origin:    opts.Origin,
+		Message:   message,
+		Sinks:     make([]*Sink, 0),
+		Requests:  make([]*Request, 0),
+		Mutations: res.Value.Int(),
+	}
+	if sinks := c.sinks.GetAll(); len(sinks) > sinksBefore {
+		result.Sinks = append(result.Sinks, sinks[sinksBefore:]...)
+	}
+	if exchanges := c.network.Exchanges(); len(exchanges) > exchangesBefore {
+		for _, ex := range exchanges[exchangesBefore:] {
+			result.Requests = append(result.Requests, ex.Request)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+(function() {
+	'use strict';
+
+	if (window.__htcrawl_messages__) return;
+
+	var MAX_OBSERVED = 20;
+	var MAX_SOURCE_LENGTH = 2000;
+	var registry = {
+		listeners: [],
+		observed: [],
+		lastId: 0
+	};
+	window.__htcrawl_messages__ = registry;
+
+	var addEventListener = EventTarget.prototype.addEventListener;
+	var removeEventListener = EventTarget.prototype.removeEventListener;
+	var fnToString = Function.prototype.toString;
+
+	function originCheck(source) {
+		if (!/\borigin\b/.test(source)) return "none";
+		if (/\borigin\s*\.\s*(indexOf|includes|startsWith|endsWith|match|search)\s*\(|\.test\s*\([^)]*\borigin\b/.test(source)) return "weak";
+		if (/\borigin\s*(===?|!==?)|(===?|!==?)\s*[\w$.\[\]'"]*\borigin\b/.test(source)) return "strict";
+		return "weak";
+	}
+
+	function listenerSource(fn) {
+		try {
+			return fnToString.call(typeof fn == "function" ? fn : fn.handleEvent);
+		} catch (e) {
+			return "";
+		}
+	}
+
+	function register(fn, kind) {
+		if (!fn || (typeof fn != "function" && typeof fn.handleEvent != "function")) return;
+		for (var l of registry.listeners) {
+			if (l.fn === fn && l.kind == kind) return;
+		}
+		var source = listenerSource(fn);
+		registry.listeners.push({
+			id: ++registry.lastId,
+			fn: fn,
+			kind: kind,
+			source: source.substring(0, MAX_SOURCE_LENGTH),
+			originCheck: originCheck(source)
+		});
+	}
+
+	function unregister(fn, kind) {
+		registry.listeners = registry.listeners.filter(function(l) {
+			return !(l.kind == kind && (fn === undefined || l.fn === fn));
+		});
+	}
+
+	function clone(data) {
+		try {
+			return JSON.parse(JSON.stringify(data));
+		} catch (e) {
+			return String(data);
+		}
+	}
+
+	function keepToString(hooked, original) {
+		hooked.toString = function() { return fnToString.call(original); };
+	}
+
+	EventTarget.prototype.addEventListener = function(type, fn) {
+		if (this === window && type === "message") register(fn, "addEventListener");
+		return addEventListener.apply(this, arguments);
+	};
+	keepToString(EventTarget.prototype.addEventListener, addEventListener);
+
+	EventTarget.prototype.removeEventListener = function(type, fn) {
+		if (this === window && type === "message") unregister(fn, "addEventListener");
+		return removeEventListener.apply(this, arguments);
+	};
+	keepToString(EventTarget.prototype.removeEventListener, removeEventListener);
+
+	try {
+		var desc = Object.getOwnPropertyDescriptor(window, "onmessage");
+		if (desc && desc.set && desc.configurable) {
+			Object.defineProperty(window, "onmessage", {
+				get: desc.get,
+				set: function(fn) {
+					unregister(undefined, "onmessage");
+					register(fn, "onmessage");
+					desc.set.call(this, fn);
+				},
+				enumerable: desc.enumerable,
+				configurable: true
+			});
+		}
+	} catch (e) { }
+
+	addEventListener.call(window, "message", function(e) {
+		if (e.htcrawlFuzz || registry.observed.length >= MAX_OBSERVED) return;
+		registry.observed.push({ origin: e.origin, data: clone(e.data) });
+	}, true);
+
+	var postMessage = window.postMessage;
+	window.postMessage = function(message, targetOrigin, transfer) {
+		var probe = window.__PROBE__;
+		if (probe && probe.options.overridePostMessage) {
+			probe.triggerPostMessageEvent(location.href, clone(message), targetOrigin, transfer);
+		}
+		return postMessage.apply(this, arguments);
+	};
+	keepToString(window.postMessage, postMessage);
+
+	registry.snapshot = function() {
+		return {
+			url: location.href,
+			listeners: registry.listeners.map(function(l) {
+				return { id: l.id, kind: l.kind, source: l.source, originCheck: l.originCheck };
+			}),
+			observed: registry.observed
+		};
+	};
+
+	registry.send = function(data, origin, wait) {
+		var mutations = 0;
+		var observer = new MutationObserver(function(records) {
+			mutations += records.length;
+		});
+		observer.observe(document.documentElement, { childList: true, subtree: true, attributes: true, characterData: true });
+
+		var ev = new MessageEvent("message", { data: data, origin: origin });
+		Object.defineProperty(ev, "htcrawlFuzz", { value: true });
+		window.dispatchEvent(ev);
+
+		return new Promise(function(resolve) {
+			var done = function() {
+				mutations += observer.takeRecords().length;
+				observer.disconnect();
+				resolve(mutations);
+			};
+			var probe = window.__PROBE__;
+			(probe ? probe.setTimeout : window.setTimeout)(done, wait);
+		});
+	};
+})();
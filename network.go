@@ -0,0 +1,209 @@
+package htcrawl
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type Response struct {
+	Status     int
+	StatusText string
+	Headers    map[string]string
+	MimeType   string
+	Body       string
+	Base64     bool
+}
+
+type Exchange struct {
+	Request  *Request
+	Response *Response
+}
+
+type NetworkRecorder struct {
+	mu        sync.RWMutex
+	exchanges []*Exchange
+	pending   map[proto.NetworkRequestID]*Exchange
+	frames    map[proto.PageFrameID]string
+}
+
+func NewNetworkRecorder() *NetworkRecorder {
+	return &NetworkRecorder{
+		exchanges: make([]*Exchange, 0),
+		pending:   make(map[proto.NetworkRequestID]*Exchange),
+		frames:    make(map[proto.PageFrameID]string),
+	}
+}
+
+func (nr *NetworkRecorder) Attach(page *rod.Page) {
+	nr.attach(page, false, "")
+}
+
+func (nr *NetworkRecorder) AttachFrame(page *rod.Page) {
+	page.EnableDomain(&proto.NetworkEnable{})
+	nr.attach(page, true, "")
+}
+
+func (nr *NetworkRecorder) AttachWorker(page *rod.Page, worker string) {
+	page.EnableDomain(&proto.NetworkEnable{})
+	nr.attach(page, false, worker)
+}
+
+func (nr *NetworkRecorder) attach(page *rod.Page, child bool, worker string) {
+	go page.EachEvent(func(e *proto.PageFrameAttached) {
+		nr.setFrame(e.FrameID, "")
+	}, func(e *proto.PageFrameNavigated) {
+		if e.Frame != nil && (child || e.Frame.ParentID != "") {
+			nr.setFrame(e.Frame.ID, e.Frame.URL)
+		}
+	}, func(e *proto.NetworkRequestWillBeSent) {
+		nr.requestWillBeSent(e, worker)
+	}, func(e *proto.NetworkResponseReceived) {
+		nr.responseReceived(e)
+	}, func(e *proto.NetworkLoadingFinished) {
+		nr.loadingFinished(page, e.RequestID)
+	}, func(e *proto.NetworkLoadingFailed) {
+		nr.loadingFinished(nil, e.RequestID)
+	})()
+}
+
+func (nr *NetworkRecorder) requestWillBeSent(e *proto.NetworkRequestWillBeSent, worker string) {
+	if e.Request == nil {
+		return
+	}
+
+	req := &Request{
+		Type:         networkRequestType(e),
+		Method:       e.Request.Method,
+		URL:          e.Request.URL,
+		Data:         e.Request.PostData,
+		ExtraHeaders: headersToMap(e.Request.Headers),
+		Worker:       worker,
+		Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	nr.mu.Lock()
+	defer nr.mu.Unlock()
+
+	if frame, ok := nr.frames[e.FrameID]; ok {
+		req.Frame = frame
+		if frame == "" && e.Type == proto.NetworkResourceTypeDocument {
+			req.Frame = e.Request.URL
+		}
+	}
+
+	if prev, ok := nr.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+		prev.Response = responseFromProto(e.RedirectResponse)
+		nr.exchanges = append(nr.exchanges, prev)
+	}
+	nr.pending[e.RequestID] = &Exchange{Request: req}
+}
+
+func (nr *NetworkRecorder) responseReceived(e *proto.NetworkResponseReceived) {
+	nr.mu.Lock()
+	defer nr.mu.Unlock()
+
+	if ex, ok := nr.pending[e.RequestID]; ok && e.Response != nil {
+		ex.Response = responseFromProto(e.Response)
+	}
+}
+
+func (nr *NetworkRecorder) loadingFinished(page *rod.Page, id proto.NetworkRequestID) {
+	nr.mu.Lock()
+	ex, ok := nr.pending[id]
+	delete(nr.pending, id)
+	nr.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if page != nil && ex.Response != nil {
+		body, err := proto.NetworkGetResponseBody{RequestID: id}.Call(page)
+		if err == nil {
+			ex.Response.Body = body.Body
+			ex.Response.Base64 = body.Base64Encoded
+		}
+	}
+
+	nr.mu.Lock()
+	nr.exchanges = append(nr.exchanges, ex)
+	nr.mu.Unlock()
+}
+
+func (nr *NetworkRecorder) setFrame(id proto.PageFrameID, url string) {
+	nr.mu.Lock()
+	defer nr.mu.Unlock()
+	if url != "" || nr.frames[id] == "" {
+		nr.frames[id] = url
+	}
+}
+
+func (nr *NetworkRecorder) Exchanges() []*Exchange {
+	nr.mu.RLock()
+	defer nr.mu.RUnlock()
+	result := make([]*Exchange, len(nr.exchanges))
+	copy(result, nr.exchanges)
+	return result
+}
+
+func (nr *NetworkRecorder) Clear() {
+	nr.mu.Lock()
+	defer nr.mu.Unlock()
+	nr.exchanges = make([]*Exchange, 0)
+	nr.pending = make(map[proto.NetworkRequestID]*Exchange)
+	nr.frames = make(map[proto.PageFrameID]string)
+}
+
+func responseFromProto(r *proto.NetworkResponse) *Response {
+	return &Response{
+		Status:     r.Status,
+		StatusText: r.StatusText,
+		Headers:    headersToMap(r.Headers),
+		MimeType:   r.MIMEType,
+	}
+}
+
+func headersToMap(headers proto.NetworkHeaders) map[string]string {
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		result[k] = v.Str()
+	}
+	return result
+}
+
+func networkRequestType(e *proto.NetworkRequestWillBeSent) string {
+	if e.Type == proto.NetworkResourceTypeDocument || e.Type == proto.NetworkResourceTypeOther || e.Type == "" {
+		if e.Request.Method != "" && e.Request.Method != "GET" && e.Request.Method != "HEAD" {
+			for k, v := range e.Request.Headers {
+				if !strings.EqualFold(k, "Content-Type") {
+					continue
+				}
+				ct := strings.ToLower(v.Str())
+				if strings.HasPrefix(ct, "application/x-www-form-urlencoded") || strings.HasPrefix(ct, "multipart/form-data") || strings.HasPrefix(ct, "text/plain") {
+					return "form"
+				}
+			}
+		}
+	}
+	return resourceTypeToRequestType(e.Type)
+}
+
+func resourceTypeToRequestType(t proto.NetworkResourceType) string {
+	switch t {
+	case proto.NetworkResourceTypeXHR:
+		return "xhr"
+	case proto.NetworkResourceTypeFetch:
+		return "fetch"
+	case proto.NetworkResourceTypeDocument:
+		return "navigation"
+	case proto.NetworkResourceTypeWebSocket:
+		return "websocket"
+	case "":
+		return "other"
+	}
+	return strings.ToLower(string(t))
+}
@@ -0,0 +1,448 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hashSegment    = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	nonAlnum       = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+type Schema struct {
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Content map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIDocument struct {
+	OpenAPI string                                  `json:"openapi"`
+	Info    OpenAPIInfo                             `json:"info"`
+	Servers []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths   map[string]map[string]*OpenAPIOperation `json:"paths"`
+}
+
+func (d *OpenAPIDocument) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+type OpenAPIBuilder struct {
+	Title      string
+	Version    string
+	Types      []string
+	PathPrefix string
+	servers    []string
+	operations map[string]*OpenAPIOperation
+	paths      map[string]map[string]*OpenAPIOperation
+	params     map[string]map[string]*OpenAPIParameter
+}
+
+func NewOpenAPIBuilder(title string) *OpenAPIBuilder {
+	return &OpenAPIBuilder{
+		Title:      title,
+		Version:    "1.0.0",
+		Types:      []string{"xhr", "fetch", "form"},
+		operations: make(map[string]*OpenAPIOperation),
+		paths:      make(map[string]map[string]*OpenAPIOperation),
+		params:     make(map[string]map[string]*OpenAPIParameter),
+	}
+}
+
+func (b *OpenAPIBuilder) AddRequest(req *Request) {
+	b.Add(&Exchange{Request: req})
+}
+
+func (b *OpenAPIBuilder) Add(ex *Exchange) {
+	if ex == nil || ex.Request == nil {
+		return
+	}
+	req := ex.Request
+	if len(b.Types) > 0 && !StringSliceContains(b.Types, req.Type) {
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		return
+	}
+	if b.PathPrefix != "" && !strings.HasPrefix(u.Path, b.PathPrefix) {
+		return
+	}
+
+	server := u.Scheme + "://" + u.Host
+	if !StringSliceContains(b.servers, server) {
+		b.servers = append(b.servers, server)
+	}
+
+	method := strings.ToLower(req.Method)
+	if method == "" {
+		method = "get"
+	}
+
+	template, pathParams := PathTemplate(u.Path)
+	key := method + " " + template
+
+	op, ok := b.operations[key]
+	if !ok {
+		op = &OpenAPIOperation{
+			OperationID: operationID(method, template),
+			Responses:   make(map[string]*OpenAPIResponse),
+		}
+		b.operations[key] = op
+		b.params[key] = make(map[string]*OpenAPIParameter)
+		if b.paths[template] == nil {
+			b.paths[template] = make(map[string]*OpenAPIOperation)
+		}
+		b.paths[template][method] = op
+	}
+
+	for name, value := range pathParams {
+		b.addParameter(key, name, "path", value)
+	}
+	for name, values := range u.Query() {
+		for _, value := range values {
+			b.addParameter(key, name, "query", value)
+		}
+	}
+
+	if req.Data != "" {
+		contentType := headerValue(req.ExtraHeaders, "Content-Type")
+		mediaType, schema := bodySchema(contentType, req.Data)
+		if op.RequestBody == nil {
+			op.RequestBody = &OpenAPIRequestBody{Content: make(map[string]*OpenAPIMediaType)}
+		}
+		if mt, ok := op.RequestBody.Content[mediaType]; ok {
+			mt.Schema = MergeSchemas(mt.Schema, schema)
+		} else {
+			op.RequestBody.Content[mediaType] = &OpenAPIMediaType{Schema: schema}
+		}
+	}
+
+	status := "default"
+	if ex.Response != nil && ex.Response.Status > 0 {
+		status = strconv.Itoa(ex.Response.Status)
+	}
+	resp, ok := op.Responses[status]
+	if !ok {
+		resp = &OpenAPIResponse{Description: "Observed response"}
+		if ex.Response != nil && ex.Response.StatusText != "" {
+			resp.Description = ex.Response.StatusText
+		}
+		op.Responses[status] = resp
+	}
+	if ex.Response != nil && ex.Response.Body != "" && !ex.Response.Base64 {
+		contentType := ex.Response.MimeType
+		if contentType == "" {
+			contentType = headerValue(ex.Response.Headers, "Content-Type")
+		}
+		if strings.Contains(contentType, "json") {
+			mediaType, schema := bodySchema(contentType, ex.Response.Body)
+			if resp.Content == nil {
+				resp.Content = make(map[string]*OpenAPIMediaType)
+			}
+			if mt, ok := resp.Content[mediaType]; ok {
+				mt.Schema = MergeSchemas(mt.Schema, schema)
+			} else {
+				resp.Content[mediaType] = &OpenAPIMediaType{Schema: schema}
+			}
+		}
+	}
+}
+
+func (b *OpenAPIBuilder) addParameter(key, name, in, value string) {
+	pkey := in + ":" + name
+	schema := valueSchema(value)
+	if p, ok := b.params[key][pkey]; ok {
+		p.Schema = MergeSchemas(p.Schema, schema)
+		return
+	}
+	p := &OpenAPIParameter{
+		Name:     name,
+		In:       in,
+		Required: in == "path",
+		Schema:   schema,
+	}
+	b.params[key][pkey] = p
+	op := b.operations[key]
+	op.Parameters = append(op.Parameters, p)
+}
+
+func (b *OpenAPIBuilder) Document() *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: b.Title, Version: b.Version},
+		Paths:   b.paths,
+	}
+	servers := make([]string, len(b.servers))
+	copy(servers, b.servers)
+	sort.Strings(servers)
+	for _, s := range servers {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: s})
+	}
+	for _, op := range b.operations {
+		sort.SliceStable(op.Parameters, func(i, j int) bool {
+			if op.Parameters[i].In != op.Parameters[j].In {
+				return op.Parameters[i].In < op.Parameters[j].In
+			}
+			return op.Parameters[i].Name < op.Parameters[j].Name
+		})
+	}
+	return doc
+}
+
+func InferOpenAPI(title string, exchanges []*Exchange) *OpenAPIDocument {
+	b := NewOpenAPIBuilder(title)
+	for _, ex := range exchanges {
+		b.Add(ex)
+	}
+	return b.Document()
+}
+
+func PathTemplate(path string) (string, map[string]string) {
+	params := make(map[string]string)
+	original := strings.Split(path, "/")
+	segments := make([]string, len(original))
+	copy(segments, original)
+	for i, seg := range original {
+		if seg == "" || !isParamSegment(seg) {
+			continue
+		}
+		name := "id"
+		if i > 0 && original[i-1] != "" && !isParamSegment(original[i-1]) {
+			name = paramName(original[i-1])
+		}
+		base := name
+		for n := 2; ; n++ {
+			if _, ok := params[name]; !ok {
+				break
+			}
+			name = base + strconv.Itoa(n)
+		}
+		params[name] = seg
+		segments[i] = "{" + name + "}"
+	}
+	template := strings.Join(segments, "/")
+	if template == "" {
+		template = "/"
+	}
+	return template, params
+}
+
+func isParamSegment(seg string) bool {
+	return numericSegment.MatchString(seg) || uuidSegment.MatchString(seg) || hashSegment.MatchString(seg)
+}
+
+func paramName(prev string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range prev {
+		if r == '-' || r == '_' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			sb.WriteString(strings.ToUpper(string(r)))
+			upper = false
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	name := strings.TrimSuffix(sb.String(), "s")
+	if name == "" {
+		return "id"
+	}
+	return name + "Id"
+}
+
+func operationID(method, template string) string {
+	var sb strings.Builder
+	sb.WriteString(method)
+	for _, seg := range strings.Split(template, "/") {
+		seg = strings.Trim(seg, "{}")
+		if seg == "" {
+			continue
+		}
+		seg = nonAlnum.ReplaceAllString(seg, "")
+		if seg == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	return sb.String()
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func bodySchema(contentType, body string) (string, *Schema) {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err == nil && (mediaType == "" || strings.Contains(mediaType, "json")) {
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		return mediaType, InferSchema(v)
+	}
+
+	if values, err := url.ParseQuery(body); err == nil && strings.Contains(body, "=") {
+		if mediaType == "" || mediaType == "text/plain" {
+			mediaType = "application/x-www-form-urlencoded"
+		}
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for name, vals := range values {
+			for _, val := range vals {
+				schema.Properties[name] = MergeSchemas(schema.Properties[name], valueSchema(val))
+			}
+		}
+		return mediaType, schema
+	}
+
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	return mediaType, &Schema{Type: "string"}
+}
+
+func valueSchema(value string) *Schema {
+	switch {
+	case numericSegment.MatchString(value):
+		return &Schema{Type: "integer"}
+	case uuidSegment.MatchString(value):
+		return &Schema{Type: "string", Format: "uuid"}
+	case value == "true" || value == "false":
+		return &Schema{Type: "boolean"}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return &Schema{Type: "number"}
+	}
+	return &Schema{Type: "string"}
+}
+
+func InferSchema(v interface{}) *Schema {
+	switch val := v.(type) {
+	case nil:
+		return &Schema{}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case float64:
+		if val == float64(int64(val)) {
+			return &Schema{Type: "integer"}
+		}
+		return &Schema{Type: "number"}
+	case string:
+		if uuidSegment.MatchString(val) {
+			return &Schema{Type: "string", Format: "uuid"}
+		}
+		return &Schema{Type: "string"}
+	case []interface{}:
+		var items *Schema
+		for _, item := range val {
+			items = MergeSchemas(items, InferSchema(item))
+		}
+		if items == nil {
+			items = &Schema{}
+		}
+		return &Schema{Type: "array", Items: items}
+	case map[string]interface{}:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for k, item := range val {
+			schema.Properties[k] = InferSchema(item)
+		}
+		return schema
+	}
+	return &Schema{Type: "string"}
+}
+
+func MergeSchemas(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.Type == "" {
+		return b
+	}
+	if b.Type == "" {
+		return a
+	}
+	if a.Type != b.Type {
+		if (a.Type == "integer" && b.Type == "number") || (a.Type == "number" && b.Type == "integer") {
+			return &Schema{Type: "number"}
+		}
+		return &Schema{Type: "string"}
+	}
+
+	merged := &Schema{Type: a.Type}
+	if a.Format == b.Format {
+		merged.Format = a.Format
+	}
+	switch a.Type {
+	case "object":
+		merged.Properties = make(map[string]*Schema)
+		for k, s := range a.Properties {
+			merged.Properties[k] = s
+		}
+		for k, s := range b.Properties {
+			merged.Properties[k] = MergeSchemas(merged.Properties[k], s)
+		}
+	case "array":
+		merged.Items = MergeSchemas(a.Items, b.Items)
+	}
+	return merged
+}
@@ -1,85 +1,114 @@
 package htcrawl
 
 type Options struct {
-	Verbose               bool
-	CheckAjax             bool
-	FillValues            bool
-	TriggerEvents         bool
-	CheckWebsockets       bool
-	SearchUrls            bool
-	JsonOutput            bool
-	MaxExecTime           int
-	AjaxTimeout           int
-	PrintAjaxPostData     bool
-	LoadImages            bool
-	GetCookies            bool
-	MapEvents             bool
-	CheckScriptInsertion  bool
-	CheckFetch            bool
-	HttpAuth              []string
-	TriggerAllMappedEvents bool
-	OutputMappedEvents    bool
-	OverrideTimeoutFunctions bool
-	Referer               string
-	UserAgent             string
-	AllEvents             []string
-	MouseEvents           []string
-	KeyboardEvents        []string
-	SetCookies            []Cookie
-	ExcludedUrls          []string
-	MaximumRecursion      int
-	MaximumAjaxChain      int
-	RandomSeed            string
-	InputNameMatchValue   []InputMatch
-	EventsMap             map[string][]string
-	Proxy                 string
-	LoadWithPost          bool
-	PostData              string
-	HeadlessChrome        bool
-	ExtraHeaders          map[string]string
-	OpenChromeDevtools    bool
-	ExceptionOnRedirect   bool
-	NavigationTimeout    int
-	BypassCSP             bool
-	SimulateRealEvents    bool
-	CrawlMode             string
-	BrowserLocalstorage   []LocalstorageItem
-	SkipDuplicateContent  bool
-	WindowSize            []int
-	ShowUI                bool
-	CustomUI              *CustomUI
-	OverridePostMessage   bool
-	IncludeAllOrigins     bool
+	Verbose                  bool                `json:"verbose" yaml:"verbose"`
+	CheckAjax                bool                `json:"checkAjax" yaml:"checkAjax"`
+	FillValues               bool                `json:"fillValues" yaml:"fillValues"`
+	TriggerEvents            bool                `json:"triggerEvents" yaml:"triggerEvents"`
+	CheckWebsockets          bool                `json:"checkWebsockets" yaml:"checkWebsockets"`
+	SearchUrls               bool                `json:"searchUrls" yaml:"searchUrls"`
+	JsonOutput               bool                `json:"jsonOutput" yaml:"jsonOutput"`
+	MaxExecTime              int                 `json:"maxExecTime" yaml:"maxExecTime"`
+	AjaxTimeout              int                 `json:"ajaxTimeout" yaml:"ajaxTimeout"`
+	PrintAjaxPostData        bool                `json:"printAjaxPostData" yaml:"printAjaxPostData"`
+	LoadImages               bool                `json:"loadImages" yaml:"loadImages"`
+	GetCookies               bool                `json:"getCookies" yaml:"getCookies"`
+	MapEvents                bool                `json:"mapEvents" yaml:"mapEvents"`
+	CheckScriptInsertion     bool                `json:"checkScriptInsertion" yaml:"checkScriptInsertion"`
+	CheckFetch               bool                `json:"checkFetch" yaml:"checkFetch"`
+	HttpAuth                 []string            `json:"httpAuth" yaml:"httpAuth"`
+	TriggerAllMappedEvents   bool                `json:"triggerAllMappedEvents" yaml:"triggerAllMappedEvents"`
+	OutputMappedEvents       bool                `json:"outputMappedEvents" yaml:"outputMappedEvents"`
+	OverrideTimeoutFunctions bool                `json:"overrideTimeoutFunctions" yaml:"overrideTimeoutFunctions"`
+	Referer                  string              `json:"referer" yaml:"referer"`
+	UserAgent                string              `json:"userAgent" yaml:"userAgent"`
+	AllEvents                []string            `json:"allEvents" yaml:"allEvents"`
+	MouseEvents              []string            `json:"mouseEvents" yaml:"mouseEvents"`
+	KeyboardEvents           []string            `json:"keyboardEvents" yaml:"keyboardEvents"`
+	SetCookies               []Cookie            `json:"setCookies" yaml:"setCookies"`
+	ExcludedUrls             []string            `json:"excludedUrls" yaml:"excludedUrls"`
+	MaximumRecursion         int                 `json:"maximumRecursion" yaml:"maximumRecursion"`
+	MaximumAjaxChain         int                 `json:"maximumAjaxChain" yaml:"maximumAjaxChain"`
+	RandomSeed               string              `json:"randomSeed" yaml:"randomSeed"`
+	Locale                   string              `json:"locale" yaml:"locale"`
+	InputNameMatchValue      []InputMatch        `json:"inputNameMatchValue" yaml:"inputNameMatchValue"`
+	EventsMap                map[string][]string `json:"eventsMap" yaml:"eventsMap"`
+	Proxy                    string              `json:"proxy" yaml:"proxy"`
+	LoadWithPost             bool                `json:"loadWithPost" yaml:"loadWithPost"`
+	PostData                 string              `json:"postData" yaml:"postData"`
+	HeadlessChrome           bool                `json:"headlessChrome" yaml:"headlessChrome"`
+	ExtraHeaders             map[string]string   `json:"extraHeaders" yaml:"extraHeaders"`
+	OpenChromeDevtools       bool                `json:"openChromeDevtools" yaml:"openChromeDevtools"`
+	ExceptionOnRedirect      bool                `json:"exceptionOnRedirect" yaml:"exceptionOnRedirect"`
+	NavigationTimeout        int                 `json:"navigationTimeout" yaml:"navigationTimeout"`
+	BypassCSP                bool                `json:"bypassCSP" yaml:"bypassCSP"`
+	SimulateRealEvents       bool                `json:"simulateRealEvents" yaml:"simulateRealEvents"`
+	CrawlMode                string              `json:"crawlMode" yaml:"crawlMode"`
+	BrowserLocalstorage      []LocalstorageItem  `json:"browserLocalstorage" yaml:"browserLocalstorage"`
+	SkipDuplicateContent     bool                `json:"skipDuplicateContent" yaml:"skipDuplicateContent"`
+	WindowSize               []int               `json:"windowSize" yaml:"windowSize"`
+	ShowUI                   bool                `json:"showUI" yaml:"showUI"`
+	CustomUI                 *CustomUI           `json:"customUI" yaml:"customUI"`
+	OverridePostMessage      bool                `json:"overridePostMessage" yaml:"overridePostMessage"`
+	IncludeAllOrigins        bool                `json:"includeAllOrigins" yaml:"includeAllOrigins"`
+	LoggedOut                LoggedOutIndicator  `json:"loggedOut" yaml:"loggedOut"`
+	MaxLoginAttempts         int                 `json:"maxLoginAttempts" yaml:"maxLoginAttempts"`
+	ExcludedElements         []ElementExclusion  `json:"excludedElements" yaml:"excludedElements"`
+	UploadFiles              []string            `json:"uploadFiles" yaml:"uploadFiles"`
+	CheckSinks               bool                `json:"checkSinks" yaml:"checkSinks"`
+	CheckMessageListeners    bool                `json:"checkMessageListeners" yaml:"checkMessageListeners"`
+	RecoverSourceMaps        bool                `json:"recoverSourceMaps" yaml:"recoverSourceMaps"`
+	SourceMapDir             string              `json:"sourceMapDir" yaml:"sourceMapDir"`
+	CrawlRoutes              bool                `json:"crawlRoutes" yaml:"crawlRoutes"`
+	MaxRoutes                int                 `json:"maxRoutes" yaml:"maxRoutes"`
+	PopupMode                string              `json:"popupMode" yaml:"popupMode"`
+	BypassServiceWorker      bool                `json:"bypassServiceWorker" yaml:"bypassServiceWorker"`
+	Snapshots                bool                `json:"snapshots" yaml:"snapshots"`
+	TriggerScreenshots       bool                `json:"triggerScreenshots" yaml:"triggerScreenshots"`
+	SnapshotDir              string              `json:"snapshotDir" yaml:"snapshotDir"`
 }
 
 type Cookie struct {
-	Name     string
-	Value    string
-	Domain   string
-	Path     string
-	Expires  int64
-	HttpOnly bool
-	Secure   bool
-	URL      string
+	Name     string `json:"name" yaml:"name"`
+	Value    string `json:"value" yaml:"value"`
+	Domain   string `json:"domain" yaml:"domain"`
+	Path     string `json:"path" yaml:"path"`
+	Expires  int64  `json:"expires" yaml:"expires"`
+	HttpOnly bool   `json:"httpOnly" yaml:"httpOnly"`
+	Secure   bool   `json:"secure" yaml:"secure"`
+	URL      string `json:"url" yaml:"url"`
 }
 
 type InputMatch struct {
-	Name  string
-	Value string
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type ElementExclusion struct {
+	Selector   string            `json:"selector,omitempty" yaml:"selector"`
+	Text       string            `json:"text,omitempty" yaml:"text"`
+	Href       string            `json:"href,omitempty" yaml:"href"`
+	Attributes map[string]string `json:"attributes,omitempty" yaml:"attributes"`
+}
+
+var DangerousElements = []ElementExclusion{
+	{Text: `log\s*out|sign\s*out|delete|remove|destroy|drop\s+(table|database)|reset\s+(account|password)|deactivate|unsubscribe`},
+	{Href: `log_?out|sign_?out|delete|remove|destroy`},
 }
 
 type LocalstorageItem struct {
-	Key   string
-	Value string
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
 }
 
 type CustomUI struct {
-	ExtensionPath string
+	ExtensionPath string `json:"extensionPath" yaml:"extensionPath"`
 }
 
 type Trigger struct {
-	Element string
-	Event   string
+	Element    string `json:"element"`
+	Event      string `json:"event"`
+	Screenshot string `json:"screenshot,omitempty"`
 }
 
 type Request struct {
@@ -89,32 +118,34 @@ type Request struct {
 	Data         string
 	Trigger      *Trigger
 	ExtraHeaders map[string]string
+	Frame        string
+	Worker       string
 	Timestamp    int64
 }
 
 func DefaultOptions() *Options {
 	return &Options{
-		Verbose:               false,
-		CheckAjax:             true,
-		FillValues:            true,
-		TriggerEvents:         true,
-		CheckWebsockets:       true,
-		SearchUrls:            true,
-		JsonOutput:            true,
-		MaxExecTime:           300000,
-		AjaxTimeout:           3000,
-		PrintAjaxPostData:     true,
-		LoadImages:            false,
-		GetCookies:            true,
-		MapEvents:             true,
-		CheckScriptInsertion:  true,
-		CheckFetch:            true,
-		HttpAuth:              nil,
-		TriggerAllMappedEvents: true,
-		OutputMappedEvents:    false,
+		Verbose:                  false,
+		CheckAjax:                true,
+		FillValues:               true,
+		TriggerEvents:            true,
+		CheckWebsockets:          true,
+		SearchUrls:               true,
+		JsonOutput:               true,
+		MaxExecTime:              300000,
+		AjaxTimeout:              3000,
+		PrintAjaxPostData:        true,
+		LoadImages:               false,
+		GetCookies:               true,
+		MapEvents:                true,
+		CheckScriptInsertion:     true,
+		CheckFetch:               true,
+		HttpAuth:                 nil,
+		TriggerAllMappedEvents:   true,
+		OutputMappedEvents:       false,
 		OverrideTimeoutFunctions: false,
-		Referer:               "",
-		UserAgent:             "",
+		Referer:                  "",
+		UserAgent:                "",
 		AllEvents: []string{
 			"abort", "autocomplete", "autocompleteerror", "beforecopy", "beforecut", "beforepaste",
 			"blur", "cancel", "canplay", "canplaythrough", "change", "close", "contextmenu",
@@ -128,16 +159,29 @@ func DefaultOptions() *Options {
 			"toggle", "volumechange", "waiting", "webkitfullscreenchange", "webkitfullscreenerror",
 			"wheel",
 		},
-		MouseEvents:    []string{"click"},
-		KeyboardEvents: []string{},
-		SetCookies:     []Cookie{},
-		ExcludedUrls:   []string{},
+		MouseEvents:      []string{"click"},
+		KeyboardEvents:   []string{},
+		SetCookies:       []Cookie{},
+		ExcludedUrls:     []string{},
 		MaximumRecursion: 15,
 		MaximumAjaxChain: 30,
-		RandomSeed:     "IsHOulDb34RaNd0MsTR1ngbUt1mN0t",
+		RandomSeed:       "IsHOulDb34RaNd0MsTR1ngbUt1mN0t",
+		Locale:           "en_US",
 		InputNameMatchValue: []InputMatch{
 			{Name: "mail", Value: "email"},
-			{Name: "((number)|(phone))|(^tel)", Value: "number"},
+			{Name: "iban", Value: "iban"},
+			{Name: "(card)|(^cc[-_]?num)", Value: "cardnumber"},
+			{Name: "(cvv)|(cvc)|(csc)", Value: "cvv"},
+			{Name: "(expir)|(^exp$)", Value: "cardexpiry"},
+			{Name: "(uuid)|(guid)", Value: "uuid"},
+			{Name: "(zip)|(post(al)?[-_ ]?code)|(^cap$)", Value: "postcode"},
+			{Name: "(address)|(street)", Value: "address"},
+			{Name: "(city)|(town)", Value: "city"},
+			{Name: "country", Value: "country"},
+			{Name: "(user(name)?)|(login)", Value: "username"},
+			{Name: "(full[-_ ]?name)|(^name$)", Value: "fullname"},
+			{Name: "(phone)|(mobile)|(^tel)", Value: "phone"},
+			{Name: "number", Value: "number"},
 			{Name: "(date)|(birth)", Value: "humandate"},
 			{Name: "((month)|(day))|(^mon$)", Value: "month"},
 			{Name: "year", Value: "year"},
@@ -146,15 +190,15 @@ func DefaultOptions() *Options {
 			{Name: "(surname)|(lastname)", Value: "surname"},
 		},
 		EventsMap: map[string][]string{
-			"button":  {"click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
-			"select":  {"change", "click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
-			"input":   {"change", "click", "dblclick", "blur", "focus", "keydown", "keyup", "mouseup", "mousedown"},
-			"a":       {"click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
+			"button":   {"click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
+			"select":   {"change", "click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
+			"input":    {"change", "click", "dblclick", "blur", "focus", "keydown", "keyup", "mouseup", "mousedown"},
+			"a":        {"click", "dblclick", "keydown", "keyup", "mouseup", "mousedown"},
 			"textarea": {"change", "click", "dblclick", "blur", "focus", "keydown", "keyup", "mouseup", "mousedown"},
-			"span":    {"click", "dblclick", "mouseup", "mousedown"},
-			"td":      {"click", "dblclick", "mouseup", "mousedown"},
-			"tr":      {"click", "dblclick", "mouseup", "mousedown"},
-			"div":     {"click", "dblclick", "mouseup", "mousedown"},
+			"span":     {"click", "dblclick", "mouseup", "mousedown"},
+			"td":       {"click", "dblclick", "mouseup", "mousedown"},
+			"tr":       {"click", "dblclick", "mouseup", "mousedown"},
+			"div":      {"click", "dblclick", "mouseup", "mousedown"},
 		},
 		Proxy:                 "",
 		LoadWithPost:          false,
@@ -174,6 +218,20 @@ func DefaultOptions() *Options {
 		CustomUI:              nil,
 		OverridePostMessage:   false,
 		IncludeAllOrigins:     false,
+		MaxLoginAttempts:      3,
+		ExcludedElements:      []ElementExclusion{},
+		UploadFiles:           []string{},
+		CheckSinks:            false,
+		CheckMessageListeners: false,
+		RecoverSourceMaps:     false,
+		SourceMapDir:          "",
+		CrawlRoutes:           false,
+		MaxRoutes:             50,
+		PopupMode:             PopupBlock,
+		BypassServiceWorker:   false,
+		Snapshots:             false,
+		TriggerScreenshots:    false,
+		SnapshotDir:           "",
 	}
 }
 
@@ -0,0 +1,125 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type JSONTrigger struct {
+	Element    string `json:"element"`
+	Event      string `json:"event"`
+	Screenshot string `json:"screenshot,omitempty"`
+}
+
+type JSONRequest struct {
+	Type         string            `json:"type"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	Data         *string           `json:"data"`
+	ExtraHeaders map[string]string `json:"extra_headers"`
+	Trigger      *JSONTrigger      `json:"trigger"`
+	Frame        string            `json:"frame,omitempty"`
+	Worker       string            `json:"worker,omitempty"`
+	Timestamp    int64             `json:"timestamp"`
+}
+
+func NewJSONRequest(r *Request) *JSONRequest {
+	jr := &JSONRequest{
+		Type:         r.Type,
+		Method:       r.Method,
+		URL:          r.URL,
+		ExtraHeaders: r.ExtraHeaders,
+		Frame:        r.Frame,
+		Worker:       r.Worker,
+		Timestamp:    r.Timestamp,
+	}
+	if jr.ExtraHeaders == nil {
+		jr.ExtraHeaders = map[string]string{}
+	}
+	if r.Data != "" {
+		data := r.Data
+		jr.Data = &data
+	}
+	if r.Trigger != nil {
+		jr.Trigger = &JSONTrigger{
+			Element:    r.Trigger.Element,
+			Event:      r.Trigger.Event,
+			Screenshot: r.Trigger.Screenshot,
+		}
+	}
+	return jr
+}
+
+type RequestWriter struct {
+	mu   sync.Mutex
+	enc  *json.Encoder
+	seen map[string]bool
+}
+
+func NewRequestWriter(w io.Writer) *RequestWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &RequestWriter{
+		enc:  enc,
+		seen: make(map[string]bool),
+	}
+}
+
+func (rw *RequestWriter) Write(req *Request) (bool, error) {
+	if req == nil {
+		return false, nil
+	}
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	key := req.Key()
+	if rw.seen[key] {
+		return false, nil
+	}
+	rw.seen[key] = true
+
+	if err := rw.enc.Encode(NewJSONRequest(req)); err != nil {
+		return false, fmt.Errorf("failed to write request: %w", err)
+	}
+	return true, nil
+}
+
+func (rw *RequestWriter) Count() int {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return len(rw.seen)
+}
+
+func requestFromParams(params map[string]interface{}) *Request {
+	switch v := params["request"].(type) {
+	case *Request:
+		return v
+	case map[string]interface{}:
+		req := &Request{
+			Type:         SafeString(v["type"]),
+			Method:       SafeString(v["method"]),
+			URL:          SafeString(v["url"]),
+			Data:         SafeString(v["data"]),
+			ExtraHeaders: make(map[string]string),
+			Frame:        SafeString(params["frame"]),
+			Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+		}
+		if headers, ok := v["extra_headers"].(map[string]interface{}); ok {
+			for k, h := range headers {
+				req.ExtraHeaders[k] = SafeString(h)
+			}
+		}
+		if trigger, ok := v["trigger"].(map[string]interface{}); ok {
+			req.Trigger = &Trigger{
+				Element: SafeString(trigger["element"]),
+				Event:   SafeString(trigger["event"]),
+			}
+		}
+		return req
+	}
+	return nil
+}
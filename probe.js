@@ -19,6 +19,7 @@
 		this._pendingWebsocket = [];
 		this.currentUserScriptParameters = [];
 		this._lastRequestId = 0;
+		this._lastFileInput = 0;
 		this.started_at = null;
 		this.textComparator = null;
 		this.setTimeout = window.setTimeout.bind(window);
@@ -28,6 +29,7 @@
 		this.totalDOMMutations = 0;
 		this.UI = null;
 		this.originals = {};
+		this.closedShadowRoots = new WeakMap();
 	}
 
 	Probe.prototype.getRootNodes = function(elements) {
@@ -152,98 +154,186 @@
 		return obj;
 	};
 
-	Probe.prototype.setVal = async function(el) {
-		var options = this.options;
-		var _this = this;
-
-		var ueRet = await this.dispatchProbeEvent("fillinput", { element: this.getElementSelector(el) });
-		if (ueRet === false) return;
-
-		var getv = function(type) {
-			if (!(type in _this.inputValues))
-				type = "string";
-			return _this.inputValues[type];
+	Probe.prototype.describeField = function(el, attempt) {
+		var attr = function(name) {
+			return el.getAttribute(name) || "";
 		};
-
-		var setv = function(name) {
-			var ret = getv('string');
-			for (var a = 0; a < options.inputNameMatchValue.length; a++) {
-				try {
-					var regexp = new RegExp(options.inputNameMatchValue[a].name, "gi");
-					if (name.match(regexp)) {
-						ret = getv(options.inputNameMatchValue[a].value);
-					}
-				} catch (e) {}
-			}
-			return ret;
+		var length = function(name) {
+			var v = parseInt(el.getAttribute(name));
+			return isNaN(v) ? -1 : v;
 		};
+		var tag = el.nodeName.toLowerCase();
+		var labels = el.labels ? Array.from(el.labels).map(function(l) { return l.innerText; }) : [];
 
-		var triggerChange = function() {
-			_this.trigger(el, 'input');
+		return {
+			selector: this.getElementSelector(el),
+			tag: tag,
+			type: tag == "input" ? (el.type || "text").toLowerCase() : tag,
+			name: el.name || "",
+			id: el.id || "",
+			value: (tag == "input" && (el.type == "checkbox" || el.type == "radio")) ? el.value : "",
+			checked: !!el.checked,
+			label: labels.join(" ").trim(),
+			placeholder: attr("placeholder"),
+			ariaLabel: attr("aria-label"),
+			autocomplete: attr("autocomplete"),
+			pattern: attr("pattern"),
+			min: attr("min"),
+			max: attr("max"),
+			step: attr("step"),
+			minLength: length("minlength"),
+			maxLength: length("maxlength"),
+			required: !!el.required,
+			accept: attr("accept"),
+			options: tag == "select" ? Array.from(el.options).filter(function(o) { return !o.disabled; }).map(function(o) { return o.value; }) : [],
+			form: el.form ? this.getElementSelector(el.form) : "",
+			formAction: el.form ? el.form.action : "",
+			formMethod: el.form ? (el.form.getAttribute("method") || "GET").toUpperCase() : "",
+			attempt: attempt || 0
 		};
+	};
 
-		if (el.nodeName.toLowerCase() == 'textarea') {
-			el.value = setv(el.name);
-			triggerChange();
-			return true;
+	Probe.prototype.getInputValue = async function(field) {
+		if (window.__htcrawl_input_value__) {
+			try {
+				var value = await window.__htcrawl_input_value__(field);
+				if (typeof value == "string") return value;
+			} catch (e) {}
 		}
 
-		if (el.nodeName.toLowerCase() == 'select') {
-			var opts = el.getElementsByTagName('option');
-			if (opts.length > 1) {
-				el.value = opts[opts.length - 1].value;
-			} else {
-				el.value = setv(el.name);
+		if (field.type == "checkbox" || field.type == "radio") return "true";
+		var ret = this.inputValues[field.type] || this.inputValues.string;
+		for (var a = 0; a < this.options.inputNameMatchValue.length; a++) {
+			try {
+				var regexp = new RegExp(this.options.inputNameMatchValue[a].name, "i");
+				if (field.name.match(regexp)) {
+					ret = this.inputValues[this.options.inputNameMatchValue[a].value] || ret;
+				}
+			} catch (e) {}
+		}
+		return ret;
+	};
+
+	Probe.prototype.setVal = async function(el, attempt) {
+		var tag = el.nodeName.toLowerCase();
+		var type = tag == "input" ? (el.type || "text").toLowerCase() : tag;
+
+		if (['button', 'hidden', 'submit', 'reset', 'image'].indexOf(type) != -1 || el.disabled || el.readOnly) {
+			return false;
+		}
+		if (type == 'file' && !window.__htcrawl_set_files__) {
+			return false;
+		}
+
+		var field = this.describeField(el, attempt);
+		var value = await this.getInputValue(field);
+
+		var ueRet = await this.dispatchProbeEvent("fillinput", { element: field.selector, field: field, value: value });
+		if (ueRet === false) return false;
+
+		if (window.__htcrawl_add_sink_source__) {
+			window.__htcrawl_add_sink_source__("input", field.selector, value);
+		}
+
+		if (type == 'file') {
+			var marker = String(++this._lastFileInput);
+			el.setAttribute("data-htcrawl_file_input", marker);
+			try {
+				await window.__htcrawl_set_files__({ element: '[data-htcrawl_file_input="' + marker + '"]', file: value });
+			} catch (e) {
+				return false;
+			} finally {
+				el.removeAttribute("data-htcrawl_file_input");
 			}
-			triggerChange();
 			return true;
 		}
 
-		var type = el.type.toLowerCase();
+		if (type == 'checkbox' || type == 'radio') {
+			el.checked = !/^(|false|off|no|0|unchecked)$/i.test(String(value).trim());
+		} else {
+			el.value = value;
+		}
+
+		this.trigger(el, 'input');
+		return true;
+	};
+
+	Probe.prototype.ensureFormValidity = async function(form) {
+		if (!form || typeof form.checkValidity != "function") return true;
+
+		for (var attempt = 1; attempt <= 3 && !form.checkValidity(); attempt++) {
+			var els = form.querySelectorAll("input, select, textarea");
+			for (let el of els) {
+				if (this.isExcluded(el) || el.checkValidity()) continue;
+				await this.setVal(el, attempt);
+			}
+		}
+		return form.checkValidity();
+	};
+
+	Probe.prototype.isSubmitControl = function(el) {
+		return !!el.form && el.matches('button:not([type=button]):not([type=reset]), input[type=submit], input[type=image]');
+	};
+
+	Probe.prototype.isExcluded = function(element) {
+		return !!(element && element.closest && element.closest("[data-htcrawl_crawl_excluded_element]"));
+	};
 
-		switch (type) {
-			case 'button':
-			case 'hidden':
-			case 'submit':
-			case 'file':
+	Probe.prototype.matchesExclusion = function(element, rule) {
+		var matched = false;
+		var test = function(pattern, value) {
+			try {
+				return new RegExp(pattern, "i").test(value || "");
+			} catch (e) {
 				return false;
-			case '':
-			case 'text':
-			case 'search':
-				el.value = setv(el.name);
-				break;
-			case 'radio':
-			case 'checkbox':
-				el.setAttribute('checked', !(el.getAttribute('checked')));
-				break;
-			case 'range':
-			case 'number':
-				if ('min' in el && el.min) {
-					el.value = (parseInt(el.min) + parseInt(('step' in el) ? el.step : 1));
-				} else {
-					el.value = parseInt(getv('number'));
-				}
-				break;
-			case 'password':
-			case 'color':
-			case 'date':
-			case 'email':
-			case 'month':
-			case 'time':
-			case 'url':
-			case 'week':
-			case 'tel':
-				el.value = getv(type);
-				break;
-			case 'datetime-local':
-				el.value = getv('datetimeLocal');
-				break;
-			default:
+			}
+		};
+
+		if (rule.selector) {
+			try {
+				if (!element.matches(rule.selector)) return false;
+			} catch (e) {
 				return false;
+			}
+			matched = true;
+		}
+		if (rule.text) {
+			var ownText = Array.from(element.childNodes).filter(function(n) { return n.nodeType == Node.TEXT_NODE; }).map(function(n) { return n.textContent; }).join(" ");
+			var interactive = element.matches("a, button, input, select, textarea, label, option, [role=button], [role=link], [role=menuitem], [onclick]");
+			var texts = [interactive ? element.innerText : ownText, element.getAttribute("aria-label"), element.getAttribute("title"), element.getAttribute("value")];
+			if (!texts.some(function(t) { return t && test(rule.text, t.trim()); })) return false;
+			matched = true;
 		}
+		if (rule.href) {
+			var href = element.getAttribute("href") || element.getAttribute("action") || element.getAttribute("formaction");
+			if (!href || !test(rule.href, href)) return false;
+			matched = true;
+		}
+		for (var name in (rule.attributes || {})) {
+			if (!element.hasAttribute(name) || !test(rule.attributes[name], element.getAttribute(name))) return false;
+			matched = true;
+		}
+		return matched;
+	};
 
-		triggerChange();
-		return true;
+	Probe.prototype.applyExclusions = function(root) {
+		var rules = this.options.excludedElements || [];
+		if (rules.length == 0 || !root || !root.querySelectorAll) return;
+
+		var els = [root].concat(this.querySelectorAllDeep(root, "*"));
+		for (let el of els) {
+			if (this.isExcluded(el)) continue;
+			for (var a = 0; a < rules.length; a++) {
+				if (this.matchesExclusion(el, rules[a])) {
+					el.setAttribute("data-htcrawl_crawl_excluded_element", "");
+					this.dispatchProbeEvent("excludedElement", {
+						element: this.getElementSelector(el),
+						rule: a
+					});
+					break;
+				}
+			}
+		}
 	};
 
 	Probe.prototype.fillInputValues = async function(element) {
@@ -251,18 +341,102 @@
 		element = element || document;
 		var els;
 		try {
-			els = element.querySelectorAll(inputs.join(","));
+			els = this.querySelectorAllDeep(element, inputs.join(","));
 		} catch (e) {
 			return false;
 		}
-		if (inputs.indexOf(element.nodeName.toLowerCase()) > -1) {
+		if (inputs.indexOf(element.nodeName.toLowerCase()) > -1 && !this.isExcluded(element)) {
 			await this.setVal(element);
 			this.trigger(element, 'input');
 		}
 		for (var a = 0; a < els.length; a++) {
+			if (this.isExcluded(els[a])) continue;
 			await this.setVal(els[a]);
 			this.trigger(els[a], 'input');
 		}
+		for (let frame of this.querySelectorAllDeep(element, "iframe, frame")) {
+			if (this.isExcluded(frame)) continue;
+			try {
+				var probe = frame.contentWindow && frame.contentWindow.__PROBE__;
+				if (probe && probe !== this) {
+					await probe.fillInputValues(frame.contentDocument.documentElement);
+				}
+			} catch (e) {}
+		}
+	};
+
+	Probe.prototype.getShadowRoot = function(element) {
+		return element.shadowRoot || this.closedShadowRoots.get(element) || null;
+	};
+
+	Probe.prototype.getFrameDocument = function(element) {
+		try {
+			return element.matches("iframe, frame") && element.contentDocument || null;
+		} catch (e) {
+			return null;
+		}
+	};
+
+	Probe.prototype.querySelectorAllDeep = function(root, selector) {
+		var out = Array.from(root.querySelectorAll(selector));
+		for (let el of root.querySelectorAll("*")) {
+			var shadow = this.getShadowRoot(el);
+			if (shadow) {
+				out = out.concat(this.querySelectorAllDeep(shadow, selector));
+			}
+		}
+		return out;
+	};
+
+	Probe.prototype.getDOMTree = function(root) {
+		var _this = this;
+		var out = [];
+		var walk = function(parent) {
+			for (let el of Array.from(parent.children || [])) {
+				if (el.hasAttribute("data-htcrawl_crawl_excluded_element")) continue;
+				out.push(el);
+				walk(el);
+				var shadow = _this.getShadowRoot(el);
+				if (shadow) walk(shadow);
+				var doc = _this.getFrameDocument(el);
+				if (doc && doc.documentElement) walk(doc.documentElement);
+			}
+		};
+		walk(root || document.documentElement);
+		return out;
+	};
+
+	Probe.prototype.queryDeep = function(root, selector) {
+		var parts = selector.split(" >>> ");
+		var el = null;
+		for (var a = 0; a < parts.length; a++) {
+			try {
+				el = root.querySelector(parts[a]);
+			} catch (e) {
+				return null;
+			}
+			if (!el) return null;
+			if (a < parts.length - 1) {
+				root = this.getShadowRoot(el);
+				if (!root) return null;
+			}
+		}
+		return el;
+	};
+
+	Probe.prototype.querySelectorPath = function(path) {
+		var selectors = path.indexOf("inframe/") == 0 ? path.substr(8).split(" ; ") : [path];
+		var root = document;
+		var el = null;
+		for (var a = 0; a < selectors.length; a++) {
+			el = this.queryDeep(root, selectors[a]);
+			if (!el) return null;
+			if (a < selectors.length - 1) {
+				root = this.getFrameDocument(el);
+				if (!root) return null;
+			}
+		}
+		return el;
 	};
 
 	Probe.prototype.trigger = function(el, evname) {
@@ -334,6 +508,7 @@
 	Probe.prototype.getEventsForElement = function(element) {
 		var events = [];
 		var map = this.options.eventsMap;
+		if (this.isExcluded(element)) return events;
 		try {
 			for (var selector in map) {
 				if (element.webkitMatchesSelector(selector)) {
@@ -346,13 +521,19 @@
 		return events;
 	};
 
-	Probe.prototype.triggerElementEvent = function(element, event) {
+	Probe.prototype.triggerElementEvent = async function(element, event) {
 		var teObj = { el: element, ev: event };
 		this.setTrigger({});
-		if (!event) return;
+		if (!event || this.isExcluded(element)) return;
 		if (!this.isEventTriggerable(event) || this.objectInArray(this.triggeredEvents, teObj))
 			return;
 
+		if (event == "click" && this.isSubmitControl(element)) {
+			await this.ensureFormValidity(element.form);
+		} else if (event == "submit" && element.matches("form")) {
+			await this.ensureFormValidity(element);
+		}
+
 		this.setTrigger({ element: element, event: event });
 		this.triggeredEvents.push(teObj);
 		this.trigger(element, event);
@@ -427,8 +608,9 @@
 		var ret = [];
 		var selector = "";
 		var id = element.getAttribute("id");
+		var root = element.getRootNode ? element.getRootNode() : element.ownerDocument;
 
-		if (id && id.match(/^[a-z][a-z0-9\-_:\.]*$/i) && element.ownerDocument.querySelectorAll(`#${id}`).length == 1) {
+		if (id && id.match(/^[a-z][a-z0-9\-_:\.]*$/i) && root.querySelectorAll && root.querySelectorAll(`#${id}`).length == 1) {
 			selector = "#" + id;
 		} else {
 			let p = element;
@@ -439,11 +621,14 @@
 				}
 			}
 			selector = name + (cnt > 1 ? `:nth-of-type(${cnt})` : "");
-			if (element != element.ownerDocument.documentElement && name != "body" && element.parentNode) {
+			if (element != element.ownerDocument.documentElement && name != "body" && element.parentNode && element.parentNode != root) {
 				ret.push(this._getElementSelector(element.parentNode));
 			}
 		}
 		ret.push(selector);
+		if (root.host) {
+			return this._getElementSelector(root.host) + " >>> " + ret.join(" > ");
+		}
 		return ret.join(" > ");
 	};
 
@@ -602,6 +787,72 @@
 		});
 	};
 
+	Probe.prototype.triggerPopupEvent = function(url, kind, target, method, data) {
+		if (!/^https?:/i.test(url)) return;
+		var req = new this.Request("navigation", method || "GET", url.split("#")[0], data, this.getTrigger());
+		this.dispatchProbeEvent("navigation", {
+			request: req,
+			popup: kind,
+			target: target || "_blank"
+		});
+	};
+
+	Probe.prototype.isNewWindowTarget = function(target) {
+		if (!target) return true;
+		target = String(target);
+		switch (target.toLowerCase()) {
+			case "_blank":
+				return true;
+			case "_self":
+			case "_parent":
+			case "_top":
+				return false;
+		}
+		try {
+			return !window.top.frames[target] && !window.frames[target];
+		} catch (e) {
+			return true;
+		}
+	};
+
+	Probe.prototype.hookPopups = function() {
+		var _this = this;
+		var block = this.options.popupMode == "block";
+		var baseTarget = function() {
+			var base = document.querySelector("base[target]");
+			return base ? base.getAttribute("target") : "";
+		};
+
+		var original = window.open;
+		window.open = function(url, target, features) {
+			if (_this.isNewWindowTarget(target)) {
+				var href = url ? _this.getAbsoluteUrl(String(url)) : "about:blank";
+				_this.triggerPopupEvent(href, "window.open", target);
+				if (block) return null;
+			}
+			return original.apply(this, arguments);
+		};
+
+		window.addEventListener("click", function(e) {
+			var link = e.target && e.target.closest ? e.target.closest("a[href], area[href]") : null;
+			if (!link) return;
+			var target = link.getAttribute("target") || baseTarget();
+			if (!target || !_this.isNewWindowTarget(target)) return;
+			_this.triggerPopupEvent(link.href, "link", target);
+			if (block) e.preventDefault();
+		}, true);
+
+		window.addEventListener("submit", function(e) {
+			var form = e.target;
+			if (!form || !form.matches || !form.matches("form")) return;
+			var target = (e.submitter && e.submitter.getAttribute("formtarget")) || form.getAttribute("target") || baseTarget();
+			if (!target || !_this.isNewWindowTarget(target)) return;
+			var req = _this.getFormAsRequest(form);
+			_this.triggerPopupEvent(_this.getAbsoluteUrl(req.url), "form", target, req.method, req.data);
+			if (block) e.preventDefault();
+		}, true);
+	};
+
 	Probe.prototype.triggerPostMessageEvent = async function(destination, message, targetOrigin, transfer) {
 		return await this.dispatchProbeEvent("postmessage", {
 			destination: destination,
@@ -655,24 +906,287 @@
 		var _this = this;
 		element = element || document.documentElement;
 
-		var observer = new MutationObserver(function(mutations) {
-			for (let m of mutations) {
-				for (let n of m.addedNodes) {
-					if (_this._isHTMLElement(n) || _this._isSVGElement(n)) {
-						if (_this.DOMMutations.indexOf(n) == -1) {
-							_this.DOMMutations.push(n);
-							_this.totalDOMMutations++;
+		if (!this._observer) {
+			this._observedRoots = [];
+			this._observer = new MutationObserver(function(mutations) {
+				for (let m of mutations) {
+					for (let n of m.addedNodes) {
+						if (_this._isHTMLElement(n) || _this._isSVGElement(n)) {
+							_this.applyExclusions(n);
+							_this._observeSubtree(n);
+							if (_this.DOMMutations.indexOf(n) == -1) {
+								_this.DOMMutations.push(n);
+								_this.totalDOMMutations++;
+								_this._bubbleMutation();
+							}
 						}
 					}
 				}
-			}
-		});
+			});
+		}
+		this._observeRoot(element);
+	};
 
-		observer.observe(element, {
+	Probe.prototype._observeRoot = function(root) {
+		if (!this._observer || this._observedRoots.indexOf(root) != -1) return;
+		this._observedRoots.push(root);
+		this._observer.observe(root, {
 			childList: true,
 			subtree: true
 		});
+		this._observeSubtree(root);
+	};
+
+	Probe.prototype._observeSubtree = function(node) {
+		var els = Array.from(node.querySelectorAll ? node.querySelectorAll("*") : []);
+		if (node.nodeType == Node.ELEMENT_NODE) els.unshift(node);
+		for (let el of els) {
+			var shadow = this.getShadowRoot(el);
+			if (shadow) this._observeRoot(shadow);
+			var doc = this.getFrameDocument(el);
+			var probe = doc && doc.defaultView && doc.defaultView.__PROBE__;
+			if (probe && probe !== this && doc.documentElement) {
+				probe._newMutationObserver(doc.documentElement);
+			}
+		}
+	};
+
+	Probe.prototype._bubbleMutation = function() {
+		for (var w = window; w !== w.parent; w = w.parent) {
+			try {
+				if (!w.parent.__PROBE__) break;
+				w.parent.__PROBE__.totalDOMMutations++;
+			} catch (e) {
+				break;
+			}
+		}
+	};
+
+	Probe.prototype.hookShadowRoots = function() {
+		var _this = this;
+		var original = Element.prototype.attachShadow;
+		if (typeof original != "function") return;
+		Element.prototype.attachShadow = function(init) {
+			var root = original.apply(this, arguments);
+			if (init && init.mode == "closed") {
+				_this.closedShadowRoots.set(this, root);
+			}
+			if (_this._observer) {
+				_this._observeRoot(root);
+			}
+			return root;
+		};
+
+		try {
+			if (window.parent !== window && window.parent.__PROBE__ && window.parent.__PROBE__._observer) {
+				document.addEventListener("DOMContentLoaded", function() {
+					_this._newMutationObserver(document.documentElement);
+				});
+			}
+		} catch (e) {}
+	};
+
+	Probe.prototype.hookRoutes = function() {
+		var _this = this;
+		this.lastRoute = location.href;
+
+		var wrap = function(name) {
+			var original = history[name];
+			if (typeof original != "function") return;
+			history[name] = function(state, title, url) {
+				var ret = original.apply(this, arguments);
+				if (url !== undefined && url !== null) {
+					_this.triggerRouteEvent(name);
+				}
+				return ret;
+			};
+		};
+		wrap("pushState");
+		wrap("replaceState");
+
+		window.addEventListener("hashchange", function() {
+			_this.triggerRouteEvent("hashchange");
+		}, true);
+		window.addEventListener("popstate", function() {
+			_this.triggerRouteEvent("popstate");
+		}, true);
+	};
+
+	Probe.prototype.triggerRouteEvent = function(kind) {
+		var url = location.href;
+		if (url == this.lastRoute) return;
+		var from = this.lastRoute;
+		this.lastRoute = url;
+		this.dispatchProbeEvent("route", {
+			url: url,
+			from: from,
+			kind: kind,
+			trigger: this.getTrigger()
+		});
+	};
+
+	Probe.prototype.navigateRoute = function(url) {
+		var target = new URL(url, location.href);
+		if (target.origin != location.origin) return false;
+		this.setTrigger({});
+		if (target.pathname == location.pathname && target.search == location.search && target.hash != location.hash) {
+			location.hash = target.hash;
+		} else {
+			history.pushState(null, "", target.href);
+			window.dispatchEvent(new PopStateEvent("popstate", { state: null }));
+		}
+		return true;
+	};
+
+	Probe.prototype.getRouteTables = function() {
+		var routes = [];
+		var seen = {};
+		var hashMode = /^#!?\//.test(location.hash);
+
+		var add = function(framework, path, hash, base) {
+			if (typeof path != "string") return;
+			var key = framework + " " + path + " " + hash + " " + base;
+			if (seen[key]) return;
+			seen[key] = true;
+			routes.push({ framework: framework, path: path, hash: !!hash, base: base || "" });
+		};
+		var join = function(base, path) {
+			if (!path) return base || "/";
+			if (path.charAt(0) == "/") return path;
+			return (base || "").replace(/\/+$/, "") + "/" + path;
+		};
+		var walk = function(framework, list, parent, hash, base, depth) {
+			if (!Array.isArray(list) || depth > 10) return;
+			for (var r of list) {
+				if (!r || typeof r != "object") continue;
+				var p = parent;
+				if (typeof r.path == "string") {
+					p = join(parent, r.path);
+					add(framework, p, hash, base);
+				}
+				walk(framework, r.children, p, hash, base, depth + 1);
+				walk(framework, r._loadedRoutes, p, hash, base, depth + 1);
+			}
+		};
+
+		try {
+			var fromElements = function(children, parent, base, depth) {
+				if (!children || depth > 10) return;
+				var list = Array.isArray(children) ? children : [children];
+				for (var c of list) {
+					if (Array.isArray(c)) {
+						fromElements(c, parent, base, depth + 1);
+						continue;
+					}
+					if (!c || typeof c != "object" || !c.props) continue;
+					var p = parent;
+					if (typeof c.props.path == "string") {
+						p = join(parent, c.props.path);
+						add("react", p, hashMode, base);
+					}
+					fromElements(c.props.children, p, base, depth + 1);
+				}
+			};
+
+			var fibers = [];
+			for (var el of document.querySelectorAll("body, body > *, [data-reactroot]")) {
+				if (el._reactRootContainer && el._reactRootContainer._internalRoot) {
+					fibers.push(el._reactRootContainer._internalRoot.current);
+				}
+				for (var k of Object.keys(el)) {
+					if (k.indexOf("__reactContainer$") == 0) fibers.push(el[k]);
+				}
+			}
+			var visited = 0;
+			var basename = "";
+			while (fibers.length > 0 && visited < 50000) {
+				var fiber = fibers.pop();
+				if (!fiber) continue;
+				visited++;
+				var props = fiber.memoizedProps;
+				if (props && typeof props == "object") {
+					if (typeof props.basename == "string") basename = props.basename;
+					if (props.router && Array.isArray(props.router.routes)) {
+						walk("react", props.router.routes, "", hashMode, props.router.basename || basename, 0);
+					}
+					if (Array.isArray(props.routes)) {
+						walk("react", props.routes, "", hashMode, basename, 0);
+					}
+					if (typeof props.path == "string" && (props.component || props.render || props.element)) {
+						add("react", join("", props.path), hashMode, basename);
+					}
+					var children = Array.isArray(props.children) ? props.children : [props.children];
+					for (var c of children) {
+						if (c && c.props && typeof c.props.path == "string") {
+							fromElements(props.children, "", basename, 0);
+							break;
+						}
+					}
+				}
+				if (fiber.sibling) fibers.push(fiber.sibling);
+				if (fiber.child) fibers.push(fiber.child);
+			}
+		} catch (e) { }
+
+		try {
+			var vueRouter = null;
+			var checked = 0;
+			for (var el of document.querySelectorAll("*")) {
+				if (++checked > 5000) break;
+				if (el.__vue_app__ && el.__vue_app__.config.globalProperties.$router) {
+					vueRouter = el.__vue_app__.config.globalProperties.$router;
+					break;
+				}
+				if (el.__vue__ && el.__vue__.$root && el.__vue__.$root.$router) {
+					vueRouter = el.__vue__.$root.$router;
+					break;
+				}
+			}
+			if (vueRouter) {
+				var history3 = vueRouter.options && vueRouter.options.history;
+				var hash = vueRouter.mode == "hash" || !!(history3 && history3.base && history3.base.indexOf("#") != -1);
+				var base = history3 ? (history3.base || "").split("#")[0] : (vueRouter.options && vueRouter.options.base) || "";
+				if (typeof vueRouter.getRoutes == "function") {
+					for (var r of vueRouter.getRoutes()) add("vue", r.path, hash, base);
+				} else if (vueRouter.options) {
+					walk("vue", vueRouter.options.routes, "", hash, base, 0);
+				}
+			}
+		} catch (e) { }
+
+		try {
+			var base = new URL(document.baseURI).pathname;
+			if (window.ng && typeof window.ng.getComponent == "function") {
+				var roots = typeof window.getAllAngularRootElements == "function" ? window.getAllAngularRootElements() : document.querySelectorAll("[ng-version]");
+				for (var el of roots) {
+					var comp = window.ng.getComponent(el);
+					if (!comp) continue;
+					for (var k of Object.keys(comp)) {
+						var v = comp[k];
+						if (v && Array.isArray(v.config) && typeof v.navigateByUrl == "function") {
+							walk("angular", v.config, "", hashMode, base, 0);
+						}
+					}
+				}
+			}
+			if (window.angular && typeof window.angular.element == "function") {
+				var injector = window.angular.element(document.querySelector("[ng-app], [data-ng-app]") || document.body).injector();
+				if (injector && injector.has("$route")) {
+					for (var p of Object.keys(injector.get("$route").routes)) add("angularjs", p, true, "");
+				}
+				if (injector && injector.has("$state")) {
+					for (var s of injector.get("$state").get()) {
+						if (s.url && !s.abstract) add("angularjs", s.url, true, "");
+					}
+				}
+			}
+		} catch (e) { }
+
+		return routes;
 	};
 
 	window.__PROBE__ = new Probe(options, inputValues);
+	window.__PROBE__.hookRoutes();
+	window.__PROBE__.hookShadowRoots();
+	window.__PROBE__.hookPopups();
 })();
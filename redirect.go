@@ -0,0 +1,314 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var RedirectSinks = map[string]bool{
+	"location":     true,
+	"window.open":  true,
+	"meta.refresh": true,
+}
+
+var RedirectSources = map[string]bool{
+	"url":         true,
+	"hash":        true,
+	"referrer":    true,
+	"postMessage": true,
+	"window.name": true,
+}
+
+type RedirectOptions struct {
+	Canary string `json:"canary" yaml:"canary"`
+	Wait   int    `json:"wait" yaml:"wait"`
+}
+
+func DefaultRedirectOptions() *RedirectOptions {
+	return &RedirectOptions{
+		Canary: "https://htcrawl-redirect.invalid/canary",
+		Wait:   1000,
+	}
+}
+
+type RedirectFinding struct {
+	Sink        string     `json:"sink"`
+	Source      SinkSource `json:"source"`
+	URL         string     `json:"url"`
+	Destination string     `json:"destination"`
+	Trigger     *Trigger   `json:"trigger,omitempty"`
+	Stack       string     `json:"stack"`
+	Steps       []string   `json:"steps"`
+}
+
+func RedirectSourcesOf(sink *Sink) []SinkSource {
+	sources := make([]SinkSource, 0)
+	if !RedirectSinks[sink.Sink] {
+		return sources
+	}
+	for _, s := range sink.Sources {
+		if RedirectSources[s.Type] {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+func (c *Crawler) RedirectCandidates() []*Sink {
+	candidates := make([]*Sink, 0)
+	for _, s := range c.sinks.GetAll() {
+		if len(RedirectSourcesOf(s)) > 0 {
+			candidates = append(candidates, s)
+		}
+	}
+	return candidates
+}
+
+func isCanaryDestination(destination, canary string) bool {
+	d, err := url.Parse(strings.TrimSpace(destination))
+	if err != nil {
+		return false
+	}
+	cu, err := url.Parse(canary)
+	if err != nil {
+		return false
+	}
+	return d.Host != "" && strings.EqualFold(d.Hostname(), cu.Hostname())
+}
+
+func redirectReplay(pageURL string, source SinkSource, canary string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	switch source.Type {
+	case "url":
+		query := u.Query()
+		if _, ok := query[source.Name]; !ok {
+			return "", fmt.Errorf("parameter %q not found in %s", source.Name, pageURL)
+		}
+		query.Set(source.Name, canary)
+		u.RawQuery = query.Encode()
+	case "hash":
+		fragment := u.Fragment
+		if decoded, err := url.PathUnescape(u.EscapedFragment()); err == nil {
+			fragment = decoded
+		}
+		if strings.Contains(fragment, source.Value) {
+			u.Fragment = strings.Replace(fragment, source.Value, canary, 1)
+		} else {
+			u.Fragment = canary
+		}
+		u.RawFragment = ""
+	}
+	return u.String(), nil
+}
+
+func redirectReferrer(pageURL string, source SinkSource, canary string) (string, error) {
+	if source.Name == "" {
+		return canary, nil
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set(source.Name, canary)
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/", RawQuery: query.Encode()}).String(), nil
+}
+
+func redirectMessage(message, destination, canary string) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(message), &parsed); err != nil {
+		return canary
+	}
+
+	var replace func(v interface{}) interface{}
+	replace = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case string:
+			if t != "" && strings.Contains(destination, t) {
+				return canary
+			}
+		case map[string]interface{}:
+			for k := range t {
+				t[k] = replace(t[k])
+			}
+		case []interface{}:
+			for i := range t {
+				t[i] = replace(t[i])
+			}
+		}
+		return v
+	}
+
+	switch parsed.(type) {
+	case map[string]interface{}, []interface{}:
+		return replace(parsed)
+	}
+	return canary
+}
+
+func (c *Crawler) CheckOpenRedirects(opts *RedirectOptions) ([]*RedirectFinding, error) {
+	if !c.options.CheckSinks {
+		return nil, fmt.Errorf("checkSinks option must be true to check open redirects")
+	}
+	if opts == nil {
+		opts = DefaultRedirectOptions()
+	}
+
+	findings := make([]*RedirectFinding, 0)
+	seen := make(map[string]bool)
+
+	for _, candidate := range c.RedirectCandidates() {
+		for _, source := range RedirectSourcesOf(candidate) {
+			c.mu.RLock()
+			stop := c.stop
+			c.mu.RUnlock()
+			if stop {
+				return findings, nil
+			}
+
+			key := candidate.Sink + "\x00" + source.Type + "\x00" + source.Name + "\x00" + candidate.URL
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			finding, err := c.confirmRedirect(candidate, source, opts)
+			if err != nil {
+				c.mu.Lock()
+				c.errors = append(c.errors, [2]string{"redirect", err.Error()})
+				c.mu.Unlock()
+				continue
+			}
+			if finding != nil {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+func (c *Crawler) confirmRedirect(candidate *Sink, source SinkSource, opts *RedirectOptions) (*RedirectFinding, error) {
+	pageURL := candidate.URL
+	steps := make([]string, 0)
+	referrer := ""
+
+	switch source.Type {
+	case "url", "hash":
+		replay, err := redirectReplay(pageURL, source, opts.Canary)
+		if err != nil {
+			return nil, err
+		}
+		pageURL = replay
+		steps = append(steps, "Open "+pageURL)
+	case "referrer":
+		replay, err := redirectReferrer(pageURL, source, opts.Canary)
+		if err != nil {
+			return nil, err
+		}
+		referrer = replay
+		steps = append(steps, fmt.Sprintf("Open %s from a page at %s", pageURL, referrer))
+	case "window.name":
+		steps = append(steps, fmt.Sprintf("Open %s in a window named %s", pageURL, strconv.Quote(opts.Canary)))
+	default:
+		steps = append(steps, "Open "+pageURL)
+	}
+
+	sinksBefore := c.sinks.Count()
+	exchangesBefore := len(c.network.Exchanges())
+
+	if err := c.page.Navigate("about:blank"); err != nil {
+		return nil, err
+	}
+
+	if source.Type == "window.name" {
+		name, _ := json.Marshal(opts.Canary)
+		remove, err := c.page.EvalOnNewDocument(fmt.Sprintf(`if (window === window.top) window.name = %s;`, name))
+		if err != nil {
+			return nil, err
+		}
+		defer remove()
+	}
+
+	c.mu.Lock()
+	c.allowNavigation = true
+	c.mu.Unlock()
+	wait := c.page.WaitNavigation(proto.PageLifecycleEventNameLoad)
+	_, err := proto.PageNavigate{URL: pageURL, Referrer: referrer}.Call(c.page)
+	if err == nil {
+		wait()
+	}
+	c.mu.Lock()
+	c.allowNavigation = false
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.afterNavigation(nil); err != nil {
+		return nil, err
+	}
+
+	if source.Type == "postMessage" {
+		data := redirectMessage(source.Value, candidate.Value, opts.Canary)
+		message, _ := marshalMessage(data)
+		steps = append(steps, fmt.Sprintf("Run window.postMessage(%s, \"*\")", message))
+		if _, err := c.page.Eval(`(m) => window.postMessage(m, "*")`, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if candidate.Trigger != nil && candidate.Trigger.Element != "" {
+		steps = append(steps, fmt.Sprintf("Trigger %s on %s", candidate.Trigger.Event, candidate.Trigger.Element))
+		if el, err := c.findElement(candidate.Trigger.Element); err == nil {
+			c.SetTrigger(candidate.Trigger)
+			c.dispatchElementEvent(el, candidate.Trigger.Event)
+		}
+	}
+
+	c.waitForRequestsCompletion()
+	if opts.Wait > 0 {
+		time.Sleep(time.Duration(opts.Wait) * time.Millisecond)
+	}
+
+	destination := ""
+	if sinks := c.sinks.GetAll(); len(sinks) > sinksBefore {
+		for _, s := range sinks[sinksBefore:] {
+			if RedirectSinks[s.Sink] && isCanaryDestination(s.Value, opts.Canary) {
+				destination = s.Value
+				break
+			}
+		}
+	}
+	if destination == "" {
+		if exchanges := c.network.Exchanges(); len(exchanges) > exchangesBefore {
+			for _, ex := range exchanges[exchangesBefore:] {
+				if isCanaryDestination(ex.Request.URL, opts.Canary) {
+					destination = ex.Request.URL
+					break
+				}
+			}
+		}
+	}
+	if destination == "" {
+		return nil, nil
+	}
+
+	return &RedirectFinding{
+		Sink:        candidate.Sink,
+		Source:      source,
+		URL:         pageURL,
+		Destination: destination,
+		Trigger:     candidate.Trigger,
+		Stack:       candidate.Stack,
+		Steps:       steps,
+	}, nil
+}
@@ -0,0 +1,264 @@
+package htcrawl
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Route struct {
+	URL       string   `json:"url"`
+	From      string   `json:"from,omitempty"`
+	Kind      string   `json:"kind"`
+	Framework string   `json:"framework,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Trigger   *Trigger `json:"trigger,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+type RouteTableEntry struct {
+	Framework string `json:"framework"`
+	Path      string `json:"path"`
+	Hash      bool   `json:"hash"`
+	Base      string `json:"base"`
+}
+
+func routeFromParams(params map[string]interface{}) *Route {
+	route := &Route{
+		URL:       SafeString(params["url"]),
+		From:      SafeString(params["from"]),
+		Kind:      SafeString(params["kind"]),
+		Framework: SafeString(params["framework"]),
+		Pattern:   SafeString(params["pattern"]),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	if route.URL == "" {
+		return nil
+	}
+	if trigger, ok := params["trigger"].(map[string]interface{}); ok {
+		route.Trigger = &Trigger{
+			Element: SafeString(trigger["element"]),
+			Event:   SafeString(trigger["event"]),
+		}
+	}
+	return route
+}
+
+var routeParamPattern = regexp.MustCompile(`^:([A-Za-z0-9_]+)(?:\(.*\))?[?+*]?$`)
+
+func MaterializeRoute(pattern string) (string, bool) {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		switch {
+		case routeParamPattern.MatchString(s):
+			if strings.HasSuffix(s, "?") || strings.HasSuffix(s, "*") {
+				segments[i] = ""
+			} else {
+				segments[i] = "1"
+			}
+		case strings.ContainsAny(s, "*:()"):
+			return "", false
+		}
+	}
+	p := path.Clean("/" + strings.Join(segments, "/"))
+	if strings.HasSuffix(pattern, "/") && p != "/" {
+		p += "/"
+	}
+	return p, true
+}
+
+func RouteTableURL(pageURL string, entry RouteTableEntry) string {
+	p, ok := MaterializeRoute(entry.Path)
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	if entry.Hash {
+		prefix := ""
+		if strings.HasPrefix(u.Fragment, "!") {
+			prefix = "!"
+		}
+		u.Fragment = prefix + p
+		u.RawFragment = ""
+		return u.String()
+	}
+
+	if base := strings.TrimSuffix(entry.Base, "/"); base != "" && !strings.HasPrefix(p, base+"/") && p != base {
+		p = path.Join("/"+strings.TrimPrefix(base, "/"), p)
+		if strings.HasSuffix(entry.Path, "/") {
+			p += "/"
+		}
+	}
+	u.Path = p
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+type RouteCollector struct {
+	mu      sync.RWMutex
+	seen    map[string]bool
+	crawled map[string]bool
+	routes  []*Route
+}
+
+func NewRouteCollector() *RouteCollector {
+	return &RouteCollector{
+		seen:    make(map[string]bool),
+		crawled: make(map[string]bool),
+		routes:  make([]*Route, 0),
+	}
+}
+
+func (rc *RouteCollector) Add(route *Route) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.seen[route.URL] {
+		return false
+	}
+	rc.seen[route.URL] = true
+	rc.routes = append(rc.routes, route)
+	return true
+}
+
+func (rc *RouteCollector) Seen(u string) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.seen[u]
+}
+
+func (rc *RouteCollector) MarkCrawled(u string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.crawled[u] = true
+}
+
+func (rc *RouteCollector) ResetCrawled() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.crawled = make(map[string]bool)
+}
+
+func (rc *RouteCollector) Next() *Route {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, r := range rc.routes {
+		if !rc.crawled[r.URL] {
+			rc.crawled[r.URL] = true
+			return r
+		}
+	}
+	return nil
+}
+
+func (rc *RouteCollector) GetAll() []*Route {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	result := make([]*Route, len(rc.routes))
+	copy(result, rc.routes)
+	return result
+}
+
+func (c *Crawler) Routes() []*Route {
+	return c.routes.GetAll()
+}
+
+func (c *Crawler) RouteTables() ([]RouteTableEntry, error) {
+	res, err := c.page.Eval(`() => window.__PROBE__ ? window.__PROBE__.getRouteTables() : []`)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RouteTableEntry, 0)
+	for _, r := range res.Value.Arr() {
+		entries = append(entries, RouteTableEntry{
+			Framework: r.Get("framework").Str(),
+			Path:      r.Get("path").Str(),
+			Hash:      r.Get("hash").Bool(),
+			Base:      r.Get("base").Str(),
+		})
+	}
+	return entries, nil
+}
+
+func (c *Crawler) discoverRoutes() {
+	info, err := c.page.Info()
+	if err != nil {
+		return
+	}
+	entries, err := c.RouteTables()
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		u := RouteTableURL(info.URL, e)
+		if u == "" || c.routes.Seen(u) || MatchesExcludedURL(u, c.options.ExcludedUrls) {
+			continue
+		}
+		c.dispatchProbeEvent("route", map[string]interface{}{
+			"url":       u,
+			"kind":      "table",
+			"framework": e.Framework,
+			"pattern":   e.Path,
+		})
+	}
+}
+
+func (c *Crawler) crawlRoutes() error {
+	c.routes.ResetCrawled()
+	if info, err := c.page.Info(); err == nil {
+		c.routes.MarkCrawled(info.URL)
+	}
+
+	for crawled := 0; c.options.MaxRoutes <= 0 || crawled < c.options.MaxRoutes; crawled++ {
+		c.mu.RLock()
+		stop := c.stop
+		c.mu.RUnlock()
+		if stop {
+			return nil
+		}
+
+		c.discoverRoutes()
+		route := c.routes.Next()
+		if route == nil {
+			return nil
+		}
+		if err := c.visitRoute(route); err != nil {
+			c.mu.Lock()
+			c.errors = append(c.errors, [2]string{"route", fmt.Sprintf("%s: %v", route.URL, err)})
+			c.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+func (c *Crawler) visitRoute(route *Route) error {
+	c.SetTrigger(nil)
+	res, err := c.page.Eval(`(url) => window.__PROBE__ ? window.__PROBE__.navigateRoute(url) : false`, route.URL)
+	if err != nil {
+		return err
+	}
+	if !res.Value.Bool() {
+		return fmt.Errorf("route is not reachable from the current page")
+	}
+
+	c.waitForRequestsCompletion()
+	c.recordState(c.page, nil)
+	if c.stateGraph.CurrentRevisited() {
+		return nil
+	}
+
+	if err := c.fillInputValues(nil); err != nil {
+		return err
+	}
+	return c.crawlDOM(nil)
+}
@@ -0,0 +1,441 @@
+package htcrawl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	EndpointURL       = "url"
+	EndpointPath      = "path"
+	EndpointRoute     = "route"
+	EndpointWebSocket = "websocket"
+	EndpointHost      = "host"
+)
+
+type Script struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	SourceMapURL string `json:"sourceMapUrl,omitempty"`
+	Hash         string `json:"hash"`
+	Length       int    `json:"length"`
+	Source       string `json:"-"`
+}
+
+type Endpoint struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	URL    string `json:"url,omitempty"`
+	Script string `json:"script"`
+}
+
+type Secret struct {
+	Kind    string  `json:"kind"`
+	Value   string  `json:"value"`
+	Script  string  `json:"script"`
+	Entropy float64 `json:"entropy,omitempty"`
+}
+
+type SecretPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var SecretPatterns = []SecretPattern{
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16}\b`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{Name: "gcp-service-account", Pattern: regexp.MustCompile(`"type"\s*:\s*"service_account"`)},
+	{Name: "azure-storage-key", Pattern: regexp.MustCompile(`AccountKey=[A-Za-z0-9+/]{86}==`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{Name: "gitlab-token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20}\b`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}\b`)},
+	{Name: "slack-webhook", Pattern: regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]{20,}`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{24,}\b`)},
+	{Name: "twilio-key", Pattern: regexp.MustCompile(`\bSK[0-9a-fA-F]{32}\b`)},
+	{Name: "sendgrid-key", Pattern: regexp.MustCompile(`\bSG\.[A-Za-z0-9_\-]{22}\.[A-Za-z0-9_\-]{43}\b`)},
+	{Name: "mailgun-key", Pattern: regexp.MustCompile(`\bkey-[0-9a-zA-Z]{32}\b`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+const SecretEntropyThreshold = 4.2
+
+var (
+	absoluteURLPattern  = regexp.MustCompile(`(?i)\b(?:https?|wss?)://[a-z0-9\-._~%]+(?::\d+)?(?:/[^\s"'` + "`" + `<>\\)]*)?`)
+	quotedPathPattern   = regexp.MustCompile(`["'` + "`" + `]((?:\.{0,2}/)[A-Za-z0-9_\-./%~:@!$&*+,;=?#{}]+)["'` + "`" + `]`)
+	quotedRoutePattern  = regexp.MustCompile(`["'` + "`" + `]([A-Za-z0-9_\-]+/[A-Za-z0-9_\-./%~:@!$&*+,;=?#{}]+)["'` + "`" + `]`)
+	quotedHostPattern   = regexp.MustCompile(`["'` + "`" + `]((?:[a-z0-9](?:[a-z0-9\-]*[a-z0-9])?\.)+(?:com|net|org|io|dev|app|cloud|co|ai|me|info|biz|internal|local|corp))["'` + "`" + `]`)
+	quotedStringPattern = regexp.MustCompile(`["'` + "`" + `]([A-Za-z0-9+/=_\-]{20,})["'` + "`" + `]`)
+	apiRoutePattern     = regexp.MustCompile(`(?i)(?:^|/)(?:api|rest|graphql|gql|rpc|ajax|v\d+)(?:/|$|\?)`)
+	fileRoutePattern    = regexp.MustCompile(`(?i)\.(?:php|aspx?|jsp|do|action|cgi|json|xml|html?)(?:\?|$)`)
+)
+
+var ignoredHosts = map[string]bool{
+	"www.w3.org": true,
+	"w3.org":     true,
+}
+
+var staticAssetExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".map": true, ".css": true, ".wasm": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true, ".bmp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".ogg": true, ".wav": true,
+	".pdf": true, ".zip": true, ".gz": true,
+}
+
+func ExtractEndpoints(source, base string) []*Endpoint {
+	endpoints := make([]*Endpoint, 0)
+	seen := make(map[string]bool)
+	add := func(kind, value string) {
+		key := kind + "\x00" + value
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		ep := &Endpoint{Kind: kind, Value: value}
+		if kind != EndpointHost {
+			ep.URL = resolveEndpoint(base, value)
+		}
+		endpoints = append(endpoints, ep)
+	}
+
+	for _, m := range absoluteURLPattern.FindAllString(source, -1) {
+		u, err := url.Parse(m)
+		if err != nil || u.Hostname() == "" || ignoredHosts[u.Hostname()] || !strings.Contains(u.Hostname(), ".") && u.Hostname() != "localhost" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(m), "ws") {
+			add(EndpointWebSocket, m)
+		} else {
+			add(EndpointURL, m)
+		}
+		add(EndpointHost, strings.ToLower(u.Hostname()))
+	}
+
+	for _, m := range quotedPathPattern.FindAllStringSubmatch(source, -1) {
+		path := m[1]
+		if strings.HasPrefix(path, "//") || !strings.ContainsAny(path, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			continue
+		}
+		if apiRoutePattern.MatchString(path) {
+			add(EndpointRoute, path)
+		} else {
+			add(EndpointPath, path)
+		}
+	}
+
+	for _, m := range quotedRoutePattern.FindAllStringSubmatch(source, -1) {
+		route := m[1]
+		if apiRoutePattern.MatchString(route) || fileRoutePattern.MatchString(route) {
+			add(EndpointRoute, route)
+		}
+	}
+
+	for _, m := range quotedHostPattern.FindAllStringSubmatch(source, -1) {
+		if !ignoredHosts[m[1]] {
+			add(EndpointHost, m[1])
+		}
+	}
+
+	return endpoints
+}
+
+func resolveEndpoint(base, value string) string {
+	ref, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	if ref.IsAbs() {
+		return ref.String()
+	}
+	b, err := url.Parse(base)
+	if err != nil || b.Scheme != "http" && b.Scheme != "https" {
+		return ""
+	}
+	return b.ResolveReference(ref).String()
+}
+
+func FindSecrets(source string) []*Secret {
+	secrets := make([]*Secret, 0)
+	seen := make(map[string]bool)
+
+	for _, p := range SecretPatterns {
+		for _, m := range p.Pattern.FindAllString(source, -1) {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			secrets = append(secrets, &Secret{Kind: p.Name, Value: m})
+		}
+	}
+
+	for _, m := range quotedStringPattern.FindAllStringSubmatch(source, -1) {
+		value := m[1]
+		if seen[value] || !strings.ContainsAny(value, "0123456789") || strings.Trim(value, "0123456789") == "" {
+			continue
+		}
+		entropy := shannonEntropy(value)
+		if entropy < SecretEntropyThreshold {
+			continue
+		}
+		seen[value] = true
+		secrets = append(secrets, &Secret{Kind: "high-entropy", Value: value, Entropy: math.Round(entropy*100) / 100})
+	}
+
+	return secrets
+}
+
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+type ScriptCollector struct {
+	mu        sync.RWMutex
+	hashes    map[string]bool
+	endpoints map[string]bool
+	secrets   map[string]bool
+	mapURLs   map[string]bool
+	scripts   []*Script
+	found     []*Endpoint
+	leaked    []*Secret
+	maps      []*RecoveredSourceMap
+}
+
+func NewScriptCollector() *ScriptCollector {
+	return &ScriptCollector{
+		hashes:    make(map[string]bool),
+		endpoints: make(map[string]bool),
+		secrets:   make(map[string]bool),
+		mapURLs:   make(map[string]bool),
+		scripts:   make([]*Script, 0),
+		found:     make([]*Endpoint, 0),
+		leaked:    make([]*Secret, 0),
+		maps:      make([]*RecoveredSourceMap, 0),
+	}
+}
+
+func (sc *ScriptCollector) Add(script *Script) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.hashes[script.Hash] {
+		return false
+	}
+	sc.hashes[script.Hash] = true
+	sc.scripts = append(sc.scripts, script)
+	return true
+}
+
+func (sc *ScriptCollector) AddEndpoint(ep *Endpoint) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	key := ep.Kind + "\x00" + ep.Value
+	if ep.URL != "" {
+		key = ep.Kind + "\x00" + ep.URL
+	}
+	if sc.endpoints[key] {
+		return false
+	}
+	sc.endpoints[key] = true
+	sc.found = append(sc.found, ep)
+	return true
+}
+
+func (sc *ScriptCollector) AddSecret(s *Secret) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.secrets[s.Value] {
+		return false
+	}
+	sc.secrets[s.Value] = true
+	sc.leaked = append(sc.leaked, s)
+	return true
+}
+
+func (sc *ScriptCollector) AddSourceMapURL(mapURL string) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.mapURLs[mapURL] {
+		return false
+	}
+	sc.mapURLs[mapURL] = true
+	return true
+}
+
+func (sc *ScriptCollector) AddSourceMap(m *RecoveredSourceMap) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.maps = append(sc.maps, m)
+}
+
+func (sc *ScriptCollector) Scripts() []*Script {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Script, len(sc.scripts))
+	copy(result, sc.scripts)
+	return result
+}
+
+func (sc *ScriptCollector) Endpoints() []*Endpoint {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Endpoint, len(sc.found))
+	copy(result, sc.found)
+	return result
+}
+
+func (sc *ScriptCollector) Secrets() []*Secret {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Secret, len(sc.leaked))
+	copy(result, sc.leaked)
+	return result
+}
+
+func (sc *ScriptCollector) SourceMaps() []*RecoveredSourceMap {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*RecoveredSourceMap, len(sc.maps))
+	copy(result, sc.maps)
+	return result
+}
+
+func (c *Crawler) watchScripts(page *rod.Page) error {
+	if !c.options.SearchUrls && !c.options.RecoverSourceMaps && !c.options.CheckSinks {
+		return nil
+	}
+	if _, err := (proto.DebuggerEnable{}).Call(page); err != nil {
+		return err
+	}
+	if err := (proto.DebuggerSetSkipAllPauses{Skip: true}).Call(page); err != nil {
+		return err
+	}
+
+	go page.EachEvent(func(e *proto.DebuggerScriptParsed) {
+		res, err := proto.DebuggerGetScriptSource{ScriptID: e.ScriptID}.Call(page)
+		if err != nil || res.ScriptSource == "" {
+			return
+		}
+		if strings.Contains(res.ScriptSource, "__htcrawl") || strings.Contains(res.ScriptSource, "__PROBE__") {
+			return
+		}
+		if c.options.CheckSinks && isEvalScript(e, res.ScriptSource) {
+			c.reportEvalSink(page, e, res.ScriptSource)
+		}
+		if !c.options.SearchUrls && !c.options.RecoverSourceMaps {
+			return
+		}
+
+		sum := sha256.Sum256([]byte(res.ScriptSource))
+		script := &Script{
+			ID:           string(e.ScriptID),
+			URL:          e.URL,
+			SourceMapURL: e.SourceMapURL,
+			Hash:         hex.EncodeToString(sum[:]),
+			Length:       len(res.ScriptSource),
+			Source:       res.ScriptSource,
+		}
+		if !c.scripts.Add(script) {
+			return
+		}
+
+		base := e.URL
+		if info, err := page.Info(); err == nil {
+			base = info.URL
+		}
+		if c.options.SearchUrls {
+			c.analyzeScript(script, base)
+		}
+		if c.options.RecoverSourceMaps {
+			c.recoverSourceMap(page, script, base)
+		}
+	})()
+	return nil
+}
+
+func (c *Crawler) analyzeScript(script *Script, base string) {
+	name := script.URL
+	if name == "" {
+		name = "script:" + script.ID
+	}
+
+	for _, s := range FindSecrets(script.Source) {
+		s.Script = name
+		c.scripts.AddSecret(s)
+	}
+
+	for _, ep := range ExtractEndpoints(script.Source, base) {
+		ep.Script = name
+		if !c.scripts.AddEndpoint(ep) {
+			continue
+		}
+		if !isNavigableEndpoint(ep, base, c.options.IncludeAllOrigins) {
+			continue
+		}
+		if MatchesExcludedURL(ep.URL, c.options.ExcludedUrls) {
+			continue
+		}
+		c.dispatchProbeEvent("navigation", map[string]interface{}{
+			"request": &Request{
+				Type:         "navigation",
+				Method:       "GET",
+				URL:          ep.URL,
+				ExtraHeaders: make(map[string]string),
+				Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+			},
+			"script": name,
+		})
+	}
+}
+
+func isNavigableEndpoint(ep *Endpoint, base string, allOrigins bool) bool {
+	if ep.Kind == EndpointHost || ep.Kind == EndpointWebSocket || ep.URL == "" || strings.Contains(ep.URL, "$%7B") || strings.Contains(ep.URL, "${") {
+		return false
+	}
+	u, err := url.Parse(ep.URL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	if staticAssetExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return false
+	}
+	if allOrigins {
+		return true
+	}
+	b, err := url.Parse(base)
+	return err == nil && strings.EqualFold(u.Scheme, b.Scheme) && strings.EqualFold(u.Host, b.Host)
+}
+
+func (c *Crawler) Scripts() []*Script {
+	return c.scripts.Scripts()
+}
+
+func (c *Crawler) Endpoints() []*Endpoint {
+	return c.scripts.Endpoints()
+}
+
+func (c *Crawler) Secrets() []*Secret {
+	return c.scripts.Secrets()
+}
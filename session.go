@@ -0,0 +1,219 @@
+package htcrawl
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type LoginFunc func(crawler *Crawler) error
+
+type LoggedOutIndicator struct {
+	LoginURL      string `json:"loginUrl" yaml:"loginUrl"`
+	Selector      string `json:"selector" yaml:"selector"`
+	Text          string `json:"text" yaml:"text"`
+	StatusCodes   []int  `json:"statusCodes" yaml:"statusCodes"`
+	StatusURL     string `json:"statusUrl" yaml:"statusUrl"`
+	MissingCookie string `json:"missingCookie" yaml:"missingCookie"`
+}
+
+func (i *LoggedOutIndicator) IsZero() bool {
+	return i.LoginURL == "" && i.Selector == "" && i.Text == "" && len(i.StatusCodes) == 0 && i.MissingCookie == ""
+}
+
+func (c *Crawler) OnLogin(login LoginFunc) {
+	c.mu.Lock()
+	c.login = login
+	c.mu.Unlock()
+}
+
+func (c *Crawler) watchSession(page *rod.Page) {
+	indicator := &c.options.LoggedOut
+	if len(indicator.StatusCodes) == 0 {
+		return
+	}
+
+	var statusURL *regexp.Regexp
+	if indicator.StatusURL != "" {
+		statusURL, _ = regexp.Compile(indicator.StatusURL)
+	}
+
+	go page.EachEvent(func(e *proto.NetworkResponseReceived) {
+		if e.Response == nil || e.Type == proto.NetworkResourceTypeImage || e.Type == proto.NetworkResourceTypeStylesheet {
+			return
+		}
+		if statusURL != nil && !statusURL.MatchString(e.Response.URL) {
+			return
+		}
+		for _, code := range indicator.StatusCodes {
+			if e.Response.Status == code {
+				c.mu.Lock()
+				c.sessionLost = fmt.Sprintf("status %d from %s", code, e.Response.URL)
+				c.mu.Unlock()
+				return
+			}
+		}
+	})()
+}
+
+func (c *Crawler) SessionLost() (bool, string) {
+	indicator := &c.options.LoggedOut
+	if indicator.IsZero() {
+		return false, ""
+	}
+
+	c.mu.RLock()
+	reason := c.sessionLost
+	c.mu.RUnlock()
+	if reason != "" {
+		return true, reason
+	}
+
+	if indicator.LoginURL != "" {
+		if info, err := c.page.Info(); err == nil {
+			if matched, _ := regexp.MatchString(indicator.LoginURL, info.URL); matched {
+				return true, "redirected to " + info.URL
+			}
+		}
+	}
+
+	if indicator.Selector != "" {
+		if has, _, err := c.page.Has(indicator.Selector); err == nil && has {
+			return true, "found " + indicator.Selector
+		}
+	}
+
+	if indicator.Text != "" {
+		res, err := c.page.Eval(`() => document.body ? document.body.innerText : ""`)
+		if err == nil {
+			if matched, _ := regexp.MatchString(indicator.Text, res.Value.Str()); matched {
+				return true, "page text matches " + indicator.Text
+			}
+		}
+	}
+
+	if indicator.MissingCookie != "" {
+		cookies, err := c.Cookies()
+		if err == nil {
+			found := false
+			for _, cookie := range cookies {
+				if cookie.Name == indicator.MissingCookie {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return true, "cookie " + indicator.MissingCookie + " is missing"
+			}
+		}
+	}
+
+	return false, ""
+}
+
+func (c *Crawler) checkSession(trigger *Trigger, url string, retry func() error) error {
+	lost, reason := c.SessionLost()
+	if !lost {
+		return nil
+	}
+
+	params := map[string]interface{}{"reason": reason, "url": url}
+	if trigger != nil {
+		params["trigger"] = &JSONTrigger{Element: trigger.Element, Event: trigger.Event}
+	}
+	if ret, _ := c.dispatchProbeEvent("sessionlost", params); ret == false {
+		return nil
+	}
+
+	if err := c.relogin(url); err != nil {
+		return err
+	}
+
+	if retry == nil {
+		return nil
+	}
+
+	if err := retry(); err != nil {
+		return err
+	}
+
+	if lost, _ := c.SessionLost(); lost {
+		if trigger != nil {
+			c.mu.Lock()
+			c.errors = append(c.errors, [2]string{"session", fmt.Sprintf("%s on %s ends the session", trigger.Event, trigger.Element)})
+			c.mu.Unlock()
+		}
+		return c.relogin(url)
+	}
+
+	return nil
+}
+
+func (c *Crawler) relogin(url string) error {
+	c.mu.Lock()
+	if c.loginAttempts >= c.options.MaxLoginAttempts {
+		c.stop = true
+		c.mu.Unlock()
+		return fmt.Errorf("session lost and %d login attempts exhausted", c.options.MaxLoginAttempts)
+	}
+	c.loginAttempts++
+	c.sessionLost = ""
+	login := c.login
+	c.mu.Unlock()
+
+	if login != nil {
+		if err := login(c); err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+		cookies, err := c.Cookies()
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.restoredCookies = mergeCookies(c.restoredCookies, cookies)
+		c.mu.Unlock()
+	}
+
+	c.mu.RLock()
+	restored := c.restoredCookies
+	c.mu.RUnlock()
+	if err := c.setupHeadersAndCookies(restored); err != nil {
+		return err
+	}
+
+	if _, err := c.navigateTo(url); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.relogins++
+	c.mu.Unlock()
+	if err := c.afterNavigation(nil); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.sessionLost = ""
+	c.mu.Unlock()
+
+	if lost, reason := c.SessionLost(); lost {
+		return fmt.Errorf("still logged out after login: %s", reason)
+	}
+	return nil
+}
+
+func mergeCookies(base, update []Cookie) []Cookie {
+	result := make([]Cookie, 0, len(base)+len(update))
+	index := make(map[string]int)
+	for _, cookie := range append(append([]Cookie{}, base...), update...) {
+		key := cookie.Name + "\x00" + cookie.Domain + "\x00" + cookie.Path
+		if i, ok := index[key]; ok {
+			result[i] = cookie
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, cookie)
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type SinkSource struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Sink struct {
+	Sink      string       `json:"sink"`
+	Value     string       `json:"value"`
+	Stack     string       `json:"stack"`
+	URL       string       `json:"url"`
+	Trigger   *Trigger     `json:"trigger,omitempty"`
+	Sources   []SinkSource `json:"sources,omitempty"`
+	Timestamp int64        `json:"timestamp"`
+}
+
+func (s *Sink) Tainted() bool {
+	return len(s.Sources) > 0
+}
+
+func (s *Sink) Contains(canary string) bool {
+	return canary != "" && strings.Contains(s.Value, canary)
+}
+
+func (s *Sink) Key() string {
+	return s.Sink + "\x00" + s.Value + "\x00" + s.URL
+}
+
+func sinkFromParams(params map[string]interface{}) *Sink {
+	name, ok := params["sink"].(string)
+	if !ok {
+		return nil
+	}
+
+	sink := &Sink{
+		Sink:      name,
+		Value:     SafeString(params["value"]),
+		Stack:     SafeString(params["stack"]),
+		URL:       SafeString(params["url"]),
+		Sources:   make([]SinkSource, 0),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	if trigger, ok := params["trigger"].(map[string]interface{}); ok {
+		sink.Trigger = &Trigger{
+			Element: SafeString(trigger["element"]),
+			Event:   SafeString(trigger["event"]),
+		}
+	}
+	if sources, ok := params["sources"].([]interface{}); ok {
+		for _, s := range sources {
+			if m, ok := s.(map[string]interface{}); ok {
+				sink.Sources = append(sink.Sources, SinkSource{
+					Type:  SafeString(m["type"]),
+					Name:  SafeString(m["name"]),
+					Value: SafeString(m["value"]),
+				})
+			}
+		}
+	}
+	return sink
+}
+
+type SinkCollector struct {
+	mu    sync.RWMutex
+	seen  map[string]bool
+	sinks []*Sink
+}
+
+func NewSinkCollector() *SinkCollector {
+	return &SinkCollector{
+		seen:  make(map[string]bool),
+		sinks: make([]*Sink, 0),
+	}
+}
+
+func (sc *SinkCollector) Add(sink *Sink) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	key := sink.Key()
+	if sc.seen[key] {
+		return false
+	}
+	sc.seen[key] = true
+	sc.sinks = append(sc.sinks, sink)
+	return true
+}
+
+func (sc *SinkCollector) GetAll() []*Sink {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Sink, len(sc.sinks))
+	copy(result, sc.sinks)
+	return result
+}
+
+func (sc *SinkCollector) Tainted() []*Sink {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Sink, 0)
+	for _, s := range sc.sinks {
+		if s.Tainted() {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (sc *SinkCollector) Containing(canary string) []*Sink {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	result := make([]*Sink, 0)
+	for _, s := range sc.sinks {
+		if s.Contains(canary) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (sc *SinkCollector) Clear() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.seen = make(map[string]bool)
+	sc.sinks = make([]*Sink, 0)
+}
+
+func (sc *SinkCollector) Count() int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return len(sc.sinks)
+}
+
+func isEvalScript(e *proto.DebuggerScriptParsed, source string) bool {
+	if e.URL != "" || e.StackTrace == nil || len(e.StackTrace.CallFrames) == 0 {
+		return false
+	}
+	return !strings.HasPrefix(source, "(function anonymous(")
+}
+
+func formatStackTrace(st *proto.RuntimeStackTrace) string {
+	lines := make([]string, 0, len(st.CallFrames))
+	for _, f := range st.CallFrames {
+		name := f.FunctionName
+		if name == "" {
+			name = "<anonymous>"
+		}
+		lines = append(lines, fmt.Sprintf("at %s (%s:%d:%d)", name, f.URL, f.LineNumber+1, f.ColumnNumber+1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Crawler) reportEvalSink(page *rod.Page, e *proto.DebuggerScriptParsed, source string) {
+	code, err := json.Marshal(source)
+	if err != nil {
+		return
+	}
+	stack, err := json.Marshal(formatStackTrace(e.StackTrace))
+	if err != nil {
+		return
+	}
+	_, _ = proto.RuntimeEvaluate{
+		Expression: fmt.Sprintf(`window.__htcrawl_report_sink__ && window.__htcrawl_report_sink__("eval", %s, false, %s)`, code, stack),
+		ContextID:  e.ExecutionContextID,
+	}.Call(page)
+}
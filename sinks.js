@@ -0,0 +1,276 @@
+(function() {
+	'use strict';
+
+	if (window.__htcrawl_sinks__) return;
+	window.__htcrawl_sinks__ = true;
+
+	var MIN_SOURCE_LENGTH = 4;
+	var MAX_VALUE_LENGTH = 4096;
+	var sources = [];
+	var depth = 0;
+	var caller = null;
+
+	function probe() {
+		return window.__PROBE__;
+	}
+
+	function addSource(type, name, value) {
+		if (value === null || value === undefined) return;
+		if (typeof value != "string") {
+			try {
+				value = JSON.stringify(value);
+			} catch (e) {
+				return;
+			}
+		}
+		if (!value || value.length < MIN_SOURCE_LENGTH) return;
+		for (var s of sources) {
+			if (s.type == type && s.name == name && s.value == value) return;
+		}
+		sources.push({ type: type, name: String(name || ""), value: value });
+	}
+
+	function collectLocationSources() {
+		try {
+			new URLSearchParams(location.search).forEach(function(v, k) {
+				addSource("url", k, v);
+			});
+		} catch (e) { }
+		var hash = location.hash.substring(1);
+		if (hash) {
+			addSource("hash", "", hash);
+			try {
+				addSource("hash", "", decodeURIComponent(hash));
+			} catch (e) { }
+		}
+		if (document.referrer) {
+			addSource("referrer", "", document.referrer);
+			try {
+				new URL(document.referrer).searchParams.forEach(function(v, k) {
+					addSource("referrer", k, v);
+				});
+			} catch (e) { }
+		}
+		addSource("window.name", "", window.name);
+		for (var name of ["localStorage", "sessionStorage"]) {
+			try {
+				var storage = window[name];
+				for (var i = 0; i < storage.length; i++) {
+					var key = storage.key(i);
+					addSource("storage", name + "." + key, storage.getItem(key));
+				}
+			} catch (e) { }
+		}
+	}
+
+	function correlate(value) {
+		collectLocationSources();
+		var decoded = value;
+		try {
+			decoded = decodeURIComponent(value);
+		} catch (e) { }
+		var ret = [];
+		for (var s of sources) {
+			if (value.indexOf(s.value) != -1 || decoded.indexOf(s.value) != -1) {
+				ret.push(s);
+			}
+		}
+		return ret;
+	}
+
+	function stackTrace() {
+		var holder = {};
+		if (Error.captureStackTrace) {
+			Error.captureStackTrace(holder, caller || stackTrace);
+		} else {
+			holder.stack = (new Error()).stack;
+		}
+		return String(holder.stack || "").split("\n").slice(1).map(function(l) { return l.trim(); }).join("\n");
+	}
+
+	function isDangerousURL(value) {
+		return /^\s*(javascript|data|vbscript):/i.test(value);
+	}
+
+	function report(sink, value, urlSink, stack) {
+		var p = probe();
+		if (!p || depth > 0 || value === null || value === undefined) return;
+		value = String(value);
+		if (!value) return;
+		var matched = correlate(value);
+		if (urlSink && matched.length == 0 && !isDangerousURL(value)) return;
+		p.dispatchProbeEvent("sink", {
+			sink: sink,
+			value: value.substring(0, MAX_VALUE_LENGTH),
+			stack: stack || stackTrace(),
+			url: location.href,
+			trigger: p.getTrigger(),
+			sources: matched
+		});
+	}
+
+	function wrap(fn, before) {
+		var wrapper = function() {
+			caller = wrapper;
+			try {
+				before.apply(this, arguments);
+			} finally {
+				caller = null;
+			}
+			depth++;
+			try {
+				return fn.apply(this, arguments);
+			} finally {
+				depth--;
+			}
+		};
+		return wrapper;
+	}
+
+	function hookMethod(obj, name, before) {
+		if (!obj || typeof obj[name] != "function") return;
+		var original = obj[name];
+		obj[name] = wrap(original, before);
+		obj[name].toString = function() { return original.toString(); };
+	}
+
+	function hookSetter(proto, prop, sink, urlSink) {
+		if (!proto) return;
+		var desc = Object.getOwnPropertyDescriptor(proto, prop);
+		if (!desc || !desc.set || !desc.configurable) return;
+		Object.defineProperty(proto, prop, {
+			get: desc.get,
+			set: wrap(desc.set, function(value) {
+				report(sink, value, urlSink);
+			}),
+			enumerable: desc.enumerable,
+			configurable: true
+		});
+	}
+
+	hookSetter(Element.prototype, "innerHTML", "innerHTML");
+	hookSetter(Element.prototype, "outerHTML", "outerHTML");
+	hookSetter(ShadowRoot.prototype, "innerHTML", "innerHTML");
+
+	hookMethod(Element.prototype, "insertAdjacentHTML", function(position, html) {
+		report("insertAdjacentHTML", html);
+	});
+	hookMethod(Document.prototype, "write", function() {
+		report("document.write", Array.prototype.join.call(arguments, ""));
+	});
+	hookMethod(Document.prototype, "writeln", function() {
+		report("document.writeln", Array.prototype.join.call(arguments, ""));
+	});
+	hookMethod(window, "setTimeout", function(code) {
+		if (typeof code == "string") report("setTimeout", code);
+	});
+	hookMethod(window, "setInterval", function(code) {
+		if (typeof code == "string") report("setInterval", code);
+	});
+
+	var OriginalFunction = window.Function;
+	var HookedFunction = function() {
+		caller = HookedFunction;
+		try {
+			report("Function", Array.prototype.join.call(arguments, ","));
+		} finally {
+			caller = null;
+		}
+		depth++;
+		try {
+			return OriginalFunction.apply(this, arguments);
+		} finally {
+			depth--;
+		}
+	};
+	HookedFunction.prototype = OriginalFunction.prototype;
+	HookedFunction.toString = function() { return OriginalFunction.toString(); };
+	window.Function = HookedFunction;
+
+	var urlSinks = [
+		[window.HTMLScriptElement, "script", "src"],
+		[window.HTMLIFrameElement, "iframe", "src"],
+		[window.HTMLFrameElement, "frame", "src"],
+		[window.HTMLEmbedElement, "embed", "src"],
+		[window.HTMLObjectElement, "object", "data"],
+		[window.HTMLAnchorElement, "a", "href"],
+		[window.HTMLAreaElement, "area", "href"],
+		[window.HTMLFormElement, "form", "action"],
+		[window.HTMLBaseElement, "base", "href"]
+	];
+	for (var u of urlSinks) {
+		if (u[0]) hookSetter(u[0].prototype, u[2], u[1] + "." + u[2], true);
+	}
+
+	hookMethod(Element.prototype, "setAttribute", function(name, value) {
+		name = String(name).toLowerCase();
+		if (/^on/.test(name)) {
+			report("setAttribute." + name, value);
+		} else if (["src", "href", "action", "formaction", "data", "srcdoc"].indexOf(name) != -1) {
+			report(this.nodeName.toLowerCase() + "." + name, value, name != "srcdoc");
+		}
+	});
+
+	if (window.navigation && window.navigation.addEventListener) {
+		window.navigation.addEventListener("navigate", function(e) {
+			if (e.destination && e.navigationType != "reload" && e.navigationType != "traverse") {
+				report("location", e.destination.url, true);
+			}
+		});
+	}
+
+	hookMethod(window, "open", function(url) {
+		if (url !== undefined) report("window.open", url, true);
+	});
+
+	function metaRefreshURL(node) {
+		if (node.nodeName != "META" || !/^refresh$/i.test(node.getAttribute("http-equiv") || "")) return null;
+		var m = /url\s*=\s*['"]?([^'"]*)/i.exec(node.getAttribute("content") || "");
+		return m ? m[1].trim() : null;
+	}
+	new MutationObserver(function(mutations) {
+		for (var m of mutations) {
+			for (var n of m.addedNodes) {
+				var nodes = n.querySelectorAll ? [n].concat(Array.from(n.querySelectorAll("meta"))) : [];
+				for (var node of nodes) {
+					var url = metaRefreshURL(node);
+					if (url) report("meta.refresh", url, true);
+				}
+			}
+		}
+	}).observe(document, { childList: true, subtree: true });
+
+	function hookJQuery(jq) {
+		if (!jq || !jq.fn || jq.fn.__htcrawl_hooked__) return;
+		jq.fn.__htcrawl_hooked__ = true;
+		for (var m of ["html", "append", "prepend", "after", "before", "replaceWith"]) {
+			(function(method) {
+				hookMethod(jq.fn, method, function(value) {
+					if (typeof value == "string" && (method == "html" || /</.test(value))) {
+						report("jquery." + method, value);
+					}
+				});
+			})(m);
+		}
+	}
+
+	var jQueryValue = window.jQuery;
+	hookJQuery(jQueryValue);
+	try {
+		Object.defineProperty(window, "jQuery", {
+			get: function() { return jQueryValue; },
+			set: function(v) {
+				jQueryValue = v;
+				hookJQuery(v);
+			},
+			configurable: true
+		});
+	} catch (e) { }
+
+	window.addEventListener("message", function(e) {
+		addSource("postMessage", e.origin, e.data);
+	}, true);
+
+	window.__htcrawl_add_sink_source__ = addSource;
+	window.__htcrawl_report_sink__ = report;
+})();
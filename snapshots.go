@@ -0,0 +1,120 @@
+package htcrawl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+type Snapshot struct {
+	State      string       `json:"state"`
+	URL        string       `json:"url"`
+	Screenshot string       `json:"screenshot,omitempty"`
+	HTML       string       `json:"html,omitempty"`
+	Trigger    *JSONTrigger `json:"trigger,omitempty"`
+}
+
+type SnapshotStore struct {
+	mu        sync.RWMutex
+	dir       string
+	written   map[string]bool
+	snapshots []*Snapshot
+}
+
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{
+		dir:       dir,
+		written:   make(map[string]bool),
+		snapshots: make([]*Snapshot, 0),
+	}
+}
+
+func (s *SnapshotStore) Write(data []byte, ext string) (string, error) {
+	sum := sha256.Sum256(data)
+	file := filepath.Join(s.dir, hex.EncodeToString(sum[:])+ext)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.written[file] {
+		return file, nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(file); err != nil {
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return "", err
+		}
+	}
+	s.written[file] = true
+	return file, nil
+}
+
+func (s *SnapshotStore) Add(snapshot *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+}
+
+func (s *SnapshotStore) GetAll() []*Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*Snapshot, len(s.snapshots))
+	copy(result, s.snapshots)
+	return result
+}
+
+func (c *Crawler) captureState(page *rod.Page, node *StateNode, trigger *Trigger) *Snapshot {
+	snapshot := &Snapshot{State: node.ID, URL: node.URL}
+	if trigger != nil {
+		snapshot.Trigger = &JSONTrigger{Element: trigger.Element, Event: trigger.Event, Screenshot: trigger.Screenshot}
+	}
+
+	bin, err := page.Screenshot(true, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err == nil {
+		snapshot.Screenshot, err = c.snapshots.Write(bin, ".png")
+	}
+	if err != nil {
+		c.addSnapshotError(node.URL, err)
+	}
+
+	res, err := page.Eval(`() => (document.doctype ? new XMLSerializer().serializeToString(document.doctype) + "\n" : "") + document.documentElement.outerHTML`)
+	if err == nil {
+		snapshot.HTML, err = c.snapshots.Write([]byte(res.Value.Str()), ".html")
+	}
+	if err != nil {
+		c.addSnapshotError(node.URL, err)
+	}
+
+	c.stateGraph.SetSnapshot(node.ID, snapshot.Screenshot, snapshot.HTML)
+	c.snapshots.Add(snapshot)
+	c.dispatchProbeEvent("snapshot", map[string]interface{}{
+		"snapshot": snapshot,
+	})
+	return snapshot
+}
+
+func (c *Crawler) captureTrigger(el *rod.Element, trigger *Trigger) {
+	bin, err := el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+	if err != nil {
+		return
+	}
+	if trigger.Screenshot, err = c.snapshots.Write(bin, ".png"); err != nil {
+		c.addSnapshotError(trigger.Element, err)
+	}
+}
+
+func (c *Crawler) addSnapshotError(target string, err error) {
+	c.mu.Lock()
+	c.errors = append(c.errors, [2]string{"snapshot", TruncateString(target, 200) + ": " + err.Error()})
+	c.mu.Unlock()
+}
+
+func (c *Crawler) Snapshots() []*Snapshot {
+	return c.snapshots.GetAll()
+}
@@ -0,0 +1,270 @@
+package htcrawl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var sourceMappingURLPattern = regexp.MustCompile(`(?m)[#@]\s*sourceMappingURL=(\S+)\s*(?:\*/)?\s*$`)
+
+type SourceMap struct {
+	Version        int                `json:"version"`
+	File           string             `json:"file"`
+	SourceRoot     string             `json:"sourceRoot"`
+	Sources        []string           `json:"sources"`
+	SourcesContent []*string          `json:"sourcesContent"`
+	Sections       []SourceMapSection `json:"sections"`
+}
+
+type SourceMapSection struct {
+	Map *SourceMap `json:"map"`
+}
+
+type OriginalSource struct {
+	Name    string
+	Content string
+}
+
+type RecoveredSourceMap struct {
+	Script  string   `json:"script"`
+	URL     string   `json:"url"`
+	Sources []string `json:"sources"`
+	Files   []string `json:"files,omitempty"`
+}
+
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, ")]}'") {
+		text = text[strings.IndexByte(text, '\n')+1:]
+	}
+	var m SourceMap
+	if err := json.Unmarshal([]byte(text), &m); err != nil {
+		return nil, fmt.Errorf("invalid source map: %w", err)
+	}
+	return &m, nil
+}
+
+func (m *SourceMap) Originals() []*OriginalSource {
+	originals := make([]*OriginalSource, 0)
+	for i, s := range m.Sources {
+		if i >= len(m.SourcesContent) || m.SourcesContent[i] == nil {
+			continue
+		}
+		name := s
+		if m.SourceRoot != "" && !strings.Contains(s, "://") {
+			name = strings.TrimSuffix(m.SourceRoot, "/") + "/" + strings.TrimPrefix(s, "/")
+		}
+		originals = append(originals, &OriginalSource{Name: name, Content: *m.SourcesContent[i]})
+	}
+	for _, section := range m.Sections {
+		if section.Map != nil {
+			originals = append(originals, section.Map.Originals()...)
+		}
+	}
+	return originals
+}
+
+func SourceMapURLOf(script *Script, headers map[string]string) string {
+	ref := script.SourceMapURL
+	if ref == "" {
+		for k, v := range headers {
+			if strings.EqualFold(k, "SourceMap") || strings.EqualFold(k, "X-SourceMap") {
+				ref = v
+				break
+			}
+		}
+	}
+	if ref == "" {
+		if m := sourceMappingURLPattern.FindAllStringSubmatch(script.Source, -1); len(m) > 0 {
+			ref = m[len(m)-1][1]
+		}
+	}
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ref
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	base, err := url.Parse(script.URL)
+	if err != nil || !base.IsAbs() {
+		return u.String()
+	}
+	return base.ResolveReference(u).String()
+}
+
+func sourceTreeHost(host string) string {
+	host = strings.NewReplacer("/", "_", "\\", "_").Replace(host)
+	if strings.Trim(host, ".") == "" {
+		return ""
+	}
+	return host
+}
+
+func SourceTreePath(mapURL, source string) string {
+	host := "inline"
+	if u, err := url.Parse(mapURL); err == nil && sourceTreeHost(u.Host) != "" {
+		host = sourceTreeHost(u.Host)
+	}
+
+	name := source
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+		if u.Scheme != "webpack" && sourceTreeHost(u.Host) != "" {
+			host = sourceTreeHost(u.Host)
+		}
+		name = u.Opaque + u.Host + "/" + u.Path
+		if u.Scheme == "webpack" {
+			name = u.Opaque + u.Path
+		}
+	}
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "" || name == "." {
+		sum := sha256.Sum256([]byte(source))
+		name = "source-" + hex.EncodeToString(sum[:4])
+	}
+	return path.Join(host, name)
+}
+
+func WriteSourceTree(dir, mapURL string, originals []*OriginalSource) ([]string, error) {
+	files := make([]string, 0, len(originals))
+	used := make(map[string]int)
+	for _, o := range originals {
+		rel := SourceTreePath(mapURL, o.Name)
+		if n := used[rel]; n > 0 {
+			ext := path.Ext(rel)
+			rel = strings.TrimSuffix(rel, ext) + "~" + strconv.Itoa(n) + ext
+		}
+		used[rel]++
+
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		if r, err := filepath.Rel(dir, file); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return files, fmt.Errorf("source %q escapes %s", o.Name, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return files, err
+		}
+		if err := os.WriteFile(file, []byte(o.Content), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (c *Crawler) recoverSourceMap(page *rod.Page, script *Script, base string) {
+	headers := map[string]string{}
+	if script.URL != "" {
+		for _, ex := range c.network.Exchanges() {
+			if ex.Request.URL == script.URL && ex.Response != nil {
+				headers = ex.Response.Headers
+			}
+		}
+	}
+
+	mapURL := SourceMapURLOf(script, headers)
+	if mapURL == "" || !c.scripts.AddSourceMapURL(mapURL) {
+		return
+	}
+
+	if err := c.loadSourceMap(page, script, mapURL, base); err != nil {
+		c.mu.Lock()
+		c.errors = append(c.errors, [2]string{"sourcemap", fmt.Sprintf("%s: %v", TruncateString(mapURL, 200), err)})
+		c.mu.Unlock()
+	}
+}
+
+func (c *Crawler) loadSourceMap(page *rod.Page, script *Script, mapURL, base string) error {
+	data, err := fetchResource(page, mapURL)
+	if err != nil {
+		return err
+	}
+	m, err := ParseSourceMap(data)
+	if err != nil {
+		return err
+	}
+
+	originals := m.Originals()
+	recovered := &RecoveredSourceMap{
+		Script:  script.URL,
+		URL:     TruncateString(mapURL, 2048),
+		Sources: make([]string, 0, len(originals)),
+	}
+	for _, o := range originals {
+		recovered.Sources = append(recovered.Sources, o.Name)
+	}
+
+	if c.options.SourceMapDir != "" {
+		files, err := WriteSourceTree(c.options.SourceMapDir, mapURL, originals)
+		recovered.Files = files
+		if err != nil {
+			c.scripts.AddSourceMap(recovered)
+			return err
+		}
+	}
+	c.scripts.AddSourceMap(recovered)
+
+	if c.options.SearchUrls {
+		for _, o := range originals {
+			sum := sha256.Sum256([]byte(o.Content))
+			c.analyzeScript(&Script{URL: o.Name, Hash: hex.EncodeToString(sum[:]), Length: len(o.Content), Source: o.Content}, base)
+		}
+	}
+	return nil
+}
+
+func fetchResource(page *rod.Page, u string) ([]byte, error) {
+	if strings.HasPrefix(u, "data:") {
+		return decodeDataURL(u)
+	}
+	res, err := proto.NetworkLoadNetworkResource{
+		FrameID: page.FrameID,
+		URL:     u,
+		Options: &proto.NetworkLoadNetworkResourceOptions{IncludeCredentials: true},
+	}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Resource.Success {
+		if res.Resource.HTTPStatusCode != nil {
+			return nil, fmt.Errorf("HTTP %d", int(*res.Resource.HTTPStatusCode))
+		}
+		return nil, fmt.Errorf("%s", res.Resource.NetErrorName)
+	}
+	reader := rod.NewStreamReader(page, res.Resource.Stream)
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func decodeDataURL(u string) ([]byte, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(u, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	text, err := url.PathUnescape(data)
+	return []byte(text), err
+}
+
+func (c *Crawler) SourceMaps() []*RecoveredSourceMap {
+	return c.scripts.SourceMaps()
+}
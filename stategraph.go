@@ -0,0 +1,340 @@
+package htcrawl
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type StateNode struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Simhash    uint32 `json:"simhash"`
+	Nelements  int    `json:"elements"`
+	SeenCount  int    `json:"seen_count"`
+	Screenshot string `json:"screenshot,omitempty"`
+	HTML       string `json:"html,omitempty"`
+}
+
+type StateEdge struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Trigger  *JSONTrigger   `json:"trigger"`
+	Requests []*JSONRequest `json:"requests"`
+	keys     map[string]bool
+}
+
+type StateGraph struct {
+	mu       sync.RWMutex
+	dedup    *DOMDeduplicator
+	nodes    []*StateNode
+	byDOM    map[*DOMNode]*StateNode
+	edges    []*StateEdge
+	current  *StateNode
+	requests []*Request
+}
+
+func NewStateGraph() *StateGraph {
+	return &StateGraph{
+		dedup:    NewDOMDeduplicator(),
+		nodes:    make([]*StateNode, 0),
+		byDOM:    make(map[*DOMNode]*StateNode),
+		edges:    make([]*StateEdge, 0),
+		requests: make([]*Request, 0),
+	}
+}
+
+func (g *StateGraph) AddRequest(req *Request) {
+	if req == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, req)
+}
+
+func (g *StateGraph) Observe(url string, domArray []string, totDomMutations int, trigger *Trigger) *StateNode {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := g.dedup.AddNode(domArray, totDomMutations)
+	node, ok := g.byDOM[result.Node]
+	if !ok {
+		node = &StateNode{
+			ID:        fmt.Sprintf("s%d", len(g.nodes)),
+			URL:       url,
+			Simhash:   result.Node.Simhash,
+			Nelements: result.Node.Nelements,
+		}
+		g.byDOM[result.Node] = node
+		g.nodes = append(g.nodes, node)
+	}
+	node.SeenCount = result.Node.SeenCount
+
+	if g.current != nil && (trigger != nil || len(g.requests) > 0) {
+		g.addEdge(g.current, node, trigger)
+	}
+	g.requests = g.requests[:0]
+	g.current = node
+
+	return node
+}
+
+func (g *StateGraph) addEdge(from, to *StateNode, trigger *Trigger) {
+	var jt *JSONTrigger
+	if trigger != nil {
+		jt = &JSONTrigger{Element: trigger.Element, Event: trigger.Event, Screenshot: trigger.Screenshot}
+	}
+
+	var edge *StateEdge
+	for _, e := range g.edges {
+		if e.From == from.ID && e.To == to.ID && sameTrigger(e.Trigger, jt) {
+			edge = e
+			break
+		}
+	}
+	if edge == nil {
+		edge = &StateEdge{
+			From:     from.ID,
+			To:       to.ID,
+			Trigger:  jt,
+			Requests: make([]*JSONRequest, 0),
+			keys:     make(map[string]bool),
+		}
+		g.edges = append(g.edges, edge)
+	}
+
+	for _, req := range g.requests {
+		if edge.keys[req.Key()] {
+			continue
+		}
+		edge.keys[req.Key()] = true
+		edge.Requests = append(edge.Requests, NewJSONRequest(req))
+	}
+}
+
+func sameTrigger(a, b *JSONTrigger) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Element == b.Element && a.Event == b.Event
+}
+
+func (g *StateGraph) SetSnapshot(id, screenshot, html string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, n := range g.nodes {
+		if n.ID == id {
+			n.Screenshot = screenshot
+			n.HTML = html
+			return
+		}
+	}
+}
+
+func (g *StateGraph) Nodes() []*StateNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	result := make([]*StateNode, len(g.nodes))
+	copy(result, g.nodes)
+	return result
+}
+
+func (g *StateGraph) Edges() []*StateEdge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	result := make([]*StateEdge, len(g.edges))
+	copy(result, g.edges)
+	return result
+}
+
+func (g *StateGraph) Current() *StateNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.current
+}
+
+func (g *StateGraph) CurrentRevisited() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.current != nil && g.current.SeenCount > 1
+}
+
+func (g *StateGraph) PathTo(id string) []*StateEdge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.nodes) == 0 {
+		return nil
+	}
+
+	start := g.nodes[0].ID
+	prev := map[string]*StateEdge{start: nil}
+	queue := []string{start}
+	for len(queue) > 0 && prev[id] == nil && id != start {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range g.edges {
+			if e.From != cur {
+				continue
+			}
+			if _, seen := prev[e.To]; seen {
+				continue
+			}
+			prev[e.To] = e
+			queue = append(queue, e.To)
+		}
+	}
+
+	if _, ok := prev[id]; !ok {
+		return nil
+	}
+
+	path := make([]*StateEdge, 0)
+	for e := prev[id]; e != nil; e = prev[e.From] {
+		path = append([]*StateEdge{e}, path...)
+	}
+	return path
+}
+
+func (e *StateEdge) Label() string {
+	label := ""
+	if e.Trigger != nil {
+		label = e.Trigger.Event + " " + e.Trigger.Element
+	}
+	if len(e.Requests) > 0 {
+		label += fmt.Sprintf(" (%d requests)", len(e.Requests))
+	}
+	return strings.TrimSpace(label)
+}
+
+func (g *StateGraph) WriteJSON(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"nodes": g.nodes,
+		"edges": g.edges,
+	})
+}
+
+func (g *StateGraph) WriteDOT(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var sb strings.Builder
+	sb.WriteString("digraph htcrawl {\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, n := range g.nodes {
+		sb.WriteString(fmt.Sprintf("  %s [label=%s];\n", n.ID, dotQuote(fmt.Sprintf("%s\n%s\n%08x", n.ID, n.URL, n.Simhash))))
+	}
+	for _, e := range g.edges {
+		sb.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n", e.From, e.To, dotQuote(e.Label())))
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+func (g *StateGraph) WriteGraphML(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "url", For: "node", AttrName: "url", AttrType: "string"},
+			{ID: "simhash", For: "node", AttrName: "simhash", AttrType: "string"},
+			{ID: "elements", For: "node", AttrName: "elements", AttrType: "int"},
+			{ID: "element", For: "edge", AttrName: "element", AttrType: "string"},
+			{ID: "event", For: "edge", AttrName: "event", AttrType: "string"},
+			{ID: "requests", For: "edge", AttrName: "requests", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "htcrawl", EdgeDefault: "directed"},
+	}
+
+	for _, n := range g.nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: n.ID,
+			Data: []graphMLData{
+				{Key: "url", Value: n.URL},
+				{Key: "simhash", Value: fmt.Sprintf("%08x", n.Simhash)},
+				{Key: "elements", Value: fmt.Sprintf("%d", n.Nelements)},
+			},
+		})
+	}
+	for _, e := range g.edges {
+		data := make([]graphMLData, 0, 3)
+		if e.Trigger != nil {
+			data = append(data, graphMLData{Key: "element", Value: e.Trigger.Element}, graphMLData{Key: "event", Value: e.Trigger.Event})
+		}
+		reqs := make([]string, 0, len(e.Requests))
+		for _, r := range e.Requests {
+			reqs = append(reqs, r.Method+" "+r.URL)
+		}
+		data = append(data, graphMLData{Key: "requests", Value: strings.Join(reqs, "\n")})
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To, Data: data})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
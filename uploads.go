@@ -0,0 +1,250 @@
+package htcrawl
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type UploadFile struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+}
+
+type UploadCorpus struct {
+	mu      sync.Mutex
+	dir     string
+	files   []*UploadFile
+	samples map[string]*UploadFile
+	markers map[string]string
+}
+
+var sampleExtensions = []string{".png", ".pdf", ".txt", ".csv", ".zip"}
+
+var uploadMimeTypes = map[string]string{
+	".png": "image/png",
+	".pdf": "application/pdf",
+	".txt": "text/plain",
+	".csv": "text/csv",
+	".zip": "application/zip",
+}
+
+var safeExtension = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+var samplePNG, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg==")
+
+const samplePDF = "%PDF-1.4\n1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj\n2 0 obj<</Type/Pages/Kids[3 0 R]/Count 1>>endobj\n3 0 obj<</Type/Page/Parent 2 0 R/MediaBox[0 0 200 200]>>endobj\ntrailer<</Root 1 0 R>>\n%%EOF\n"
+
+func NewUploadCorpus(paths []string) *UploadCorpus {
+	u := &UploadCorpus{
+		files:   make([]*UploadFile, 0, len(paths)),
+		samples: make(map[string]*UploadFile),
+		markers: make(map[string]string),
+	}
+	for _, p := range paths {
+		u.files = append(u.files, newUploadFile(p))
+	}
+	return u
+}
+
+func newUploadFile(path string) *UploadFile {
+	ext := strings.ToLower(filepath.Ext(path))
+	mimeType, ok := uploadMimeTypes[ext]
+	if !ok {
+		mimeType = mime.TypeByExtension(ext)
+	}
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return &UploadFile{Path: path, Name: filepath.Base(path), MimeType: mimeType}
+}
+
+func (u *UploadCorpus) Files() []*UploadFile {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	result := make([]*UploadFile, len(u.files))
+	copy(result, u.files)
+	return result
+}
+
+func (u *UploadCorpus) Choose(accept string, variant int) (*UploadFile, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	tokens := parseAccept(accept)
+
+	candidates := make([]*UploadFile, 0)
+	for _, f := range u.files {
+		if acceptsFile(tokens, f) {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[variant%len(candidates)], nil
+	}
+
+	exts := make([]string, 0)
+	for _, ext := range sampleExtensions {
+		if acceptsFile(tokens, newUploadFile("sample"+ext)) {
+			exts = append(exts, ext)
+		}
+	}
+	if len(exts) == 0 {
+		for _, t := range tokens {
+			if safeExtension.MatchString(t) {
+				exts = append(exts, t)
+			} else if list, _ := mime.ExtensionsByType(t); len(list) > 0 {
+				exts = append(exts, list[0])
+			}
+		}
+	}
+	if len(exts) == 0 {
+		exts = []string{".txt"}
+	}
+
+	return u.sample(exts[variant%len(exts)])
+}
+
+func (u *UploadCorpus) sample(ext string) (*UploadFile, error) {
+	if f, ok := u.samples[ext]; ok {
+		return f, nil
+	}
+
+	if u.dir == "" {
+		dir, err := os.MkdirTemp("", "htcrawl-uploads-")
+		if err != nil {
+			return nil, err
+		}
+		u.dir = dir
+	}
+
+	data, err := SampleFile(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(u.dir, "sample"+ext)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+
+	f := newUploadFile(path)
+	u.samples[ext] = f
+	return f, nil
+}
+
+func (u *UploadCorpus) Marker(path string) (string, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.owns(path) {
+		return "", false
+	}
+	marker := fmt.Sprintf("upload-%d:%s", len(u.markers)+1, filepath.Base(path))
+	u.markers[marker] = path
+	return marker, true
+}
+
+func (u *UploadCorpus) Resolve(marker string) (string, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	path, ok := u.markers[marker]
+	if !ok || !u.owns(path) {
+		return "", false
+	}
+	return path, true
+}
+
+func (u *UploadCorpus) owns(path string) bool {
+	for _, f := range u.files {
+		if f.Path == path {
+			return true
+		}
+	}
+	for _, f := range u.samples {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UploadCorpus) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(u.dir)
+	u.dir = ""
+	u.samples = make(map[string]*UploadFile)
+	return err
+}
+
+func SampleFile(ext string) ([]byte, error) {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return samplePNG, nil
+	case ".pdf":
+		return []byte(samplePDF), nil
+	case ".csv":
+		return []byte("id,name,email\n1,john,john.smith@example.com\n"), nil
+	case ".zip":
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("sample.txt")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte("htcrawl sample file\n")); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return []byte(fmt.Sprintf("htcrawl sample file (%s)\n", strings.TrimPrefix(ext, "."))), nil
+}
+
+func parseAccept(accept string) []string {
+	tokens := make([]string, 0)
+	for _, t := range strings.Split(accept, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+func acceptsFile(tokens []string, f *UploadFile) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(f.Name))
+	for _, t := range tokens {
+		switch {
+		case strings.HasPrefix(t, "."):
+			if t == ext {
+				return true
+			}
+		case strings.HasSuffix(t, "/*"):
+			if strings.HasPrefix(f.MimeType, strings.TrimSuffix(t, "*")) {
+				return true
+			}
+		case t == f.MimeType:
+			return true
+		}
+	}
+	return false
+}
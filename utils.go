@@ -3,6 +3,7 @@ package htcrawl
 import (
 	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"net/url"
 	"regexp"
@@ -21,25 +22,20 @@ var surnames = []string{"anderson", "thomas", "jackson", "white", "harris", "mar
 var domains = []string{".com", ".org", ".net", ".it", ".tv", ".de", ".fr"}
 
 type RandomGenerator struct {
-	seed    []int
-	current int
+	r *rand.Rand
 }
 
 func NewRandomGenerator(seed string) *RandomGenerator {
-	rg := &RandomGenerator{}
-	for _, c := range seed {
-		rg.seed = append(rg.seed, int(c))
-	}
-	return rg
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	return &RandomGenerator{r: rand.New(rand.NewSource(int64(h.Sum64())))}
 }
 
 func (rg *RandomGenerator) rand(max int) int {
-	if len(rg.seed) == 0 {
+	if max <= 0 {
 		return 0
 	}
-	val := rg.seed[rg.current] % max
-	rg.current = (rg.current + 1) % len(rg.seed)
-	return val
+	return rg.r.Intn(max)
 }
 
 func (rg *RandomGenerator) randArr(arr []string) string {
@@ -57,30 +53,16 @@ func (rg *RandomGenerator) randString(length int) string {
 	return string(result)
 }
 
+func (rg *RandomGenerator) randDigits(length int) string {
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = numbers[rg.rand(len(numbers))]
+	}
+	return string(result)
+}
+
 func GenerateRandomValues(seed string) map[string]string {
-	rg := NewRandomGenerator(seed)
-	values := make(map[string]string)
-
-	values["string"] = rg.randString(8)
-	values["number"] = rg.randString(3)
-	values["month"] = rg.randArr(months)
-	values["year"] = rg.randArr(years)
-	values["date"] = rg.randArr(years) + "-" + rg.randArr(months) + "-" + rg.randArr(months)
-	values["color"] = "#" + rg.randString(6)
-	values["week"] = rg.randArr(years) + "-W" + rg.randArr(months[:6])
-	values["time"] = rg.randArr(months) + ":" + rg.randArr(months)
-	values["datetimeLocal"] = values["date"] + "T" + values["time"]
-	values["domain"] = strings.ToLower(rg.randString(12)) + rg.randArr(domains)
-	values["surname"] = rg.randArr(surnames)
-	values["firstname"] = rg.randArr(names)
-	values["email"] = values["firstname"] + "." + values["surname"] + "@" + values["domain"]
-	values["url"] = "http://www." + values["domain"]
-	values["humandate"] = rg.randArr(months) + "/" + rg.randArr(months) + "/" + rg.randArr(years)
-	values["password"] = rg.randString(3) + rg.randArr([]string{symbols}) + rg.randString(2) + rg.randString(3) + rg.randString(2)
-	values["lastname"] = values["surname"]
-	values["tel"] = "+" + rg.randString(1) + " " + rg.randString(10)
-
-	return values
+	return NewFaker(seed, "en_US").Values()
 }
 
 func ParseCookiesFromHeaders(headers map[string][]string, targetURL string) []Cookie {
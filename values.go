@@ -0,0 +1,96 @@
+package htcrawl
+
+import (
+	"regexp"
+	"sync"
+)
+
+type ValueProvider interface {
+	Value(field *InputField) (string, bool)
+}
+
+type ValueProviderFunc func(field *InputField) (string, bool)
+
+func (f ValueProviderFunc) Value(field *InputField) (string, bool) {
+	return f(field)
+}
+
+type DefaultValueProvider struct {
+	Matches []InputMatch
+	Values  map[string]string
+	Uploads *UploadCorpus
+}
+
+func (p *DefaultValueProvider) Value(field *InputField) (string, bool) {
+	if field.Type == "checkbox" || field.Type == "radio" {
+		return "true", true
+	}
+	if field.Type == "file" {
+		if p.Uploads == nil {
+			return "", false
+		}
+		file, err := p.Uploads.Choose(field.Accept, field.Attempt)
+		if err != nil {
+			return "", false
+		}
+		return file.Path, true
+	}
+	return GenerateFieldValue(field, p.Matches, p.Values), true
+}
+
+type DictionaryEntry struct {
+	Name   string
+	Values []string
+	re     *regexp.Regexp
+}
+
+type DictionaryValueProvider struct {
+	mu      sync.Mutex
+	entries []*DictionaryEntry
+}
+
+func NewDictionaryValueProvider() *DictionaryValueProvider {
+	return &DictionaryValueProvider{
+		entries: make([]*DictionaryEntry, 0),
+	}
+}
+
+func (d *DictionaryValueProvider) Add(name string, values ...string) error {
+	re, err := regexp.Compile("(?i)" + name)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(d.entries, &DictionaryEntry{Name: name, Values: values, re: re})
+	return nil
+}
+
+func (d *DictionaryValueProvider) Value(field *InputField) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, hint := range field.Hints() {
+		for _, e := range d.entries {
+			if len(e.Values) == 0 || !e.re.MatchString(hint) {
+				continue
+			}
+			return e.Values[field.Attempt%len(e.Values)], true
+		}
+	}
+	return "", false
+}
+
+func ChainValueProviders(providers ...ValueProvider) ValueProvider {
+	return ValueProviderFunc(func(field *InputField) (string, bool) {
+		for _, p := range providers {
+			if p == nil {
+				continue
+			}
+			if v, ok := p.Value(field); ok {
+				return v, true
+			}
+		}
+		return "", false
+	})
+}
@@ -0,0 +1,267 @@
+package htcrawl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	WorkerDedicated = "worker"
+	WorkerShared    = "shared_worker"
+	WorkerService   = "service_worker"
+)
+
+type Worker struct {
+	Type   string         `json:"type"`
+	URL    string         `json:"url"`
+	Scope  string         `json:"scope,omitempty"`
+	Status string         `json:"status,omitempty"`
+	Script *Script        `json:"script,omitempty"`
+	Cached []*CachedRoute `json:"cached,omitempty"`
+}
+
+type CachedRoute struct {
+	Cache  string `json:"cache"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+func isWorkerTarget(t proto.TargetTargetInfoType) bool {
+	switch string(t) {
+	case WorkerDedicated, WorkerShared, WorkerService:
+		return true
+	}
+	return false
+}
+
+type WorkerCollector struct {
+	mu       sync.RWMutex
+	attached map[proto.TargetTargetID]bool
+	index    map[string]*Worker
+	workers  []*Worker
+}
+
+func NewWorkerCollector() *WorkerCollector {
+	return &WorkerCollector{
+		attached: make(map[proto.TargetTargetID]bool),
+		index:    make(map[string]*Worker),
+		workers:  make([]*Worker, 0),
+	}
+}
+
+func (wc *WorkerCollector) Attach(id proto.TargetTargetID) bool {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.attached[id] {
+		return false
+	}
+	wc.attached[id] = true
+	return true
+}
+
+func (wc *WorkerCollector) Attached(id proto.TargetTargetID) bool {
+	wc.mu.RLock()
+	defer wc.mu.RUnlock()
+	return wc.attached[id]
+}
+
+func (wc *WorkerCollector) Add(kind, u string) (*Worker, bool) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	key := kind + " " + u
+	if w, ok := wc.index[key]; ok {
+		return w, false
+	}
+	w := &Worker{Type: kind, URL: u}
+	wc.index[key] = w
+	wc.workers = append(wc.workers, w)
+	return w, true
+}
+
+func (wc *WorkerCollector) Update(w *Worker, scope, status string, script *Script) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if scope != "" {
+		w.Scope = scope
+	}
+	if status != "" {
+		w.Status = status
+	}
+	if script != nil {
+		w.Script = script
+	}
+}
+
+func (wc *WorkerCollector) GetAll() []*Worker {
+	wc.mu.RLock()
+	defer wc.mu.RUnlock()
+	result := make([]*Worker, len(wc.workers))
+	for i, w := range wc.workers {
+		cp := *w
+		result[i] = &cp
+	}
+	return result
+}
+
+func (c *Crawler) addWorker(kind, u string) *Worker {
+	w, created := c.workers.Add(kind, u)
+	if created {
+		cp := *w
+		c.dispatchProbeEvent("worker", map[string]interface{}{
+			"worker": &cp,
+			"type":   kind,
+			"url":    u,
+		})
+	}
+	return w
+}
+
+func (c *Crawler) watchWorkers() {
+	go c.browser.EachEvent(func(e *proto.TargetTargetCreated) {
+		info := e.TargetInfo
+		if info == nil || info.Type != proto.TargetTargetInfoTypeServiceWorker && info.Type != proto.TargetTargetInfoTypeSharedWorker {
+			return
+		}
+		if c.workers.Attached(info.TargetID) {
+			return
+		}
+		session, err := proto.TargetAttachToTarget{TargetID: info.TargetID, Flatten: true}.Call(c.browser)
+		if err == nil {
+			err = c.attachWorker(c.browser.PageFromSession(session.SessionID), info)
+		}
+		if err != nil {
+			c.mu.Lock()
+			c.errors = append(c.errors, [2]string{"worker", fmt.Sprintf("%s: %v", info.URL, err)})
+			c.mu.Unlock()
+		}
+	})()
+}
+
+func (c *Crawler) watchServiceWorkers(page *rod.Page) {
+	scopes := make(map[proto.ServiceWorkerRegistrationID]string)
+	go page.EachEvent(func(e *proto.ServiceWorkerWorkerRegistrationUpdated) {
+		for _, r := range e.Registrations {
+			if !r.IsDeleted {
+				scopes[r.RegistrationID] = r.ScopeURL
+			}
+		}
+	}, func(e *proto.ServiceWorkerWorkerVersionUpdated) {
+		for _, v := range e.Versions {
+			if v.ScriptURL == "" {
+				continue
+			}
+			w := c.addWorker(WorkerService, v.ScriptURL)
+			c.workers.Update(w, scopes[v.RegistrationID], string(v.Status), nil)
+		}
+	})()
+}
+
+func (c *Crawler) attachWorker(session *rod.Page, info *proto.TargetTargetInfo) error {
+	if !c.workers.Attach(info.TargetID) {
+		return nil
+	}
+	w := c.addWorker(string(info.Type), info.URL)
+
+	c.network.AttachWorker(session, info.URL)
+	go session.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if e.Request == nil {
+			return
+		}
+		kind := resourceTypeToRequestType(e.Type)
+		if kind != "xhr" && kind != "fetch" && kind != "websocket" {
+			return
+		}
+		c.dispatchProbeEvent(kind, map[string]interface{}{
+			"request": &Request{
+				Type:         kind,
+				Method:       e.Request.Method,
+				URL:          e.Request.URL,
+				Data:         e.Request.PostData,
+				ExtraHeaders: headersToMap(e.Request.Headers),
+				Worker:       info.URL,
+				Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+			},
+			"worker": info.URL,
+		})
+	})()
+
+	if _, err := (proto.DebuggerEnable{}).Call(session); err != nil {
+		return err
+	}
+	if err := (proto.DebuggerSetSkipAllPauses{Skip: true}).Call(session); err != nil {
+		return err
+	}
+	go session.EachEvent(func(e *proto.DebuggerScriptParsed) {
+		if e.URL != info.URL {
+			return
+		}
+		res, err := proto.DebuggerGetScriptSource{ScriptID: e.ScriptID}.Call(session)
+		if err != nil {
+			return
+		}
+		sum := sha256.Sum256([]byte(res.ScriptSource))
+		script := &Script{
+			ID:           string(e.ScriptID),
+			URL:          e.URL,
+			SourceMapURL: e.SourceMapURL,
+			Hash:         hex.EncodeToString(sum[:]),
+			Length:       len(res.ScriptSource),
+			Source:       res.ScriptSource,
+		}
+		c.workers.Update(w, "", "", script)
+		if c.scripts.Add(script) && c.options.SearchUrls {
+			c.analyzeScript(script, info.URL)
+		}
+	})()
+	return nil
+}
+
+func (c *Crawler) Workers() []*Worker {
+	workers := c.workers.GetAll()
+	for _, w := range workers {
+		if w.Type == WorkerService {
+			w.Cached = c.cachedRoutes(w)
+		}
+	}
+	return workers
+}
+
+func (c *Crawler) cachedRoutes(w *Worker) []*CachedRoute {
+	scope := w.Scope
+	if scope == "" {
+		scope = w.URL
+	}
+	u, err := url.Parse(scope)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	names, err := proto.CacheStorageRequestCacheNames{SecurityOrigin: u.Scheme + "://" + u.Host}.Call(c.page)
+	if err != nil {
+		return nil
+	}
+	routes := make([]*CachedRoute, 0)
+	for _, cache := range names.Caches {
+		entries, err := proto.CacheStorageRequestEntries{CacheID: cache.CacheID}.Call(c.page)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries.CacheDataEntries {
+			routes = append(routes, &CachedRoute{
+				Cache:  cache.CacheName,
+				Method: e.RequestMethod,
+				URL:    e.RequestURL,
+				Status: e.ResponseStatus,
+			})
+		}
+	}
+	return routes
+}
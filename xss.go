@@ -0,0 +1,452 @@
+package htcrawl
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+const (
+	InjectForm         = "form"
+	InjectQuery        = "query"
+	InjectFragment     = "fragment"
+	InjectPostMessage  = "postmessage"
+	InjectLocalStorage = "localstorage"
+)
+
+var InjectionPoints = []string{InjectForm, InjectQuery, InjectFragment, InjectPostMessage, InjectLocalStorage}
+
+var XSSPayloadSets = map[string][]string{
+	"html": {
+		`<img src=x onerror=__htcrawl_xss__({canary})>`,
+		`"><svg onload=__htcrawl_xss__({canary})>`,
+		`'><img src=x onerror=__htcrawl_xss__({canary})>`,
+	},
+	"js": {
+		`__htcrawl_xss__({canary})`,
+		`';__htcrawl_xss__({canary});//`,
+		`";__htcrawl_xss__({canary});//`,
+	},
+	"url": {
+		`javascript:__htcrawl_xss__({canary})`,
+	},
+	"dialog": {
+		`<img src=x onerror=alert({canary})>`,
+		`';alert({canary});//`,
+	},
+}
+
+var DefaultXSSPayloads = append(append(append([]string{}, XSSPayloadSets["html"]...), XSSPayloadSets["js"]...), XSSPayloadSets["url"]...)
+
+var xssFormFieldTypes = map[string]bool{
+	"text": true, "search": true, "textarea": true, "url": true,
+	"email": true, "tel": true, "password": true,
+}
+
+type XSSScanOptions struct {
+	Payloads        []string `json:"payloads" yaml:"payloads"`
+	InjectionPoints []string `json:"injectionPoints" yaml:"injectionPoints"`
+	StorageKeys     []string `json:"storageKeys" yaml:"storageKeys"`
+}
+
+func DefaultXSSScanOptions() *XSSScanOptions {
+	return &XSSScanOptions{
+		Payloads:        DefaultXSSPayloads,
+		InjectionPoints: InjectionPoints,
+		StorageKeys:     []string{},
+	}
+}
+
+type XSSFinding struct {
+	InjectionPoint string   `json:"injectionPoint"`
+	Parameter      string   `json:"parameter"`
+	Payload        string   `json:"payload"`
+	URL            string   `json:"url"`
+	Confirmation   string   `json:"confirmation"`
+	Trigger        *Trigger `json:"trigger,omitempty"`
+	Sink           *Sink    `json:"sink,omitempty"`
+	Steps          []string `json:"steps"`
+}
+
+func (f *XSSFinding) Key() string {
+	u := f.URL
+	if parsed, err := url.Parse(f.URL); err == nil {
+		parsed.RawQuery = ""
+		parsed.Fragment = ""
+		u = parsed.String()
+	}
+	return f.InjectionPoint + "\x00" + f.Parameter + "\x00" + u
+}
+
+type xssInjection struct {
+	point     string
+	parameter string
+	payload   string
+	url       string
+	steps     []string
+}
+
+type XSSScanner struct {
+	crawler   *Crawler
+	scan      *XSSScanOptions
+	mu        sync.Mutex
+	seq       int
+	payload   string
+	injected  map[string]*xssInjection
+	findings  []*XSSFinding
+	seen      map[string]bool
+	onFinding func(*XSSFinding)
+}
+
+func newXSSScanner(scan *XSSScanOptions) *XSSScanner {
+	if scan == nil {
+		scan = DefaultXSSScanOptions()
+	}
+	return &XSSScanner{
+		scan:     scan,
+		injected: make(map[string]*xssInjection),
+		findings: make([]*XSSFinding, 0),
+		seen:     make(map[string]bool),
+	}
+}
+
+func NewXSSScanner(targetURL string, options *Options, scan *XSSScanOptions) (*XSSScanner, error) {
+	crawler, err := Launch(targetURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newXSSScanner(scan)
+	s.crawler = crawler
+
+	if _, err := crawler.page.Expose("__htcrawl_xss__", s.handleCallback); err != nil {
+		crawler.Close()
+		return nil, err
+	}
+
+	page := crawler.page
+	go page.EachEvent(func(e *proto.PageJavascriptDialogOpening) {
+		s.confirm(strings.TrimSpace(e.Message), "dialog")
+		_ = proto.PageHandleJavaScriptDialog{Accept: true}.Call(page)
+	})()
+
+	if s.enabled(InjectForm) {
+		crawler.SetValueProvider(ValueProviderFunc(s.formValue))
+	}
+
+	return s, nil
+}
+
+func (s *XSSScanner) Crawler() *Crawler {
+	return s.crawler
+}
+
+func (s *XSSScanner) OnFinding(fn func(*XSSFinding)) {
+	s.mu.Lock()
+	s.onFinding = fn
+	s.mu.Unlock()
+}
+
+func (s *XSSScanner) Close() error {
+	return s.crawler.Close()
+}
+
+func (s *XSSScanner) Findings() []*XSSFinding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*XSSFinding, len(s.findings))
+	copy(result, s.findings)
+	return result
+}
+
+func (s *XSSScanner) Scan() ([]*XSSFinding, error) {
+	for _, payload := range s.scan.Payloads {
+		s.crawler.mu.RLock()
+		stop := s.crawler.stop
+		s.crawler.mu.RUnlock()
+		if stop {
+			break
+		}
+
+		if err := s.round(payload); err != nil {
+			s.crawler.mu.Lock()
+			s.crawler.errors = append(s.crawler.errors, [2]string{"xss", err.Error()})
+			s.crawler.mu.Unlock()
+		}
+	}
+	return s.Findings(), nil
+}
+
+func (s *XSSScanner) round(payload string) error {
+	c := s.crawler
+
+	s.mu.Lock()
+	s.payload = payload
+	s.mu.Unlock()
+
+	// A target that differs only in the fragment would be a same-document
+	// navigation that never fires a load event.
+	c.SetTrigger(nil)
+	if err := c.page.Navigate("about:blank"); err != nil {
+		return err
+	}
+
+	if s.enabled(InjectLocalStorage) {
+		if _, err := c.navigateTo(c.targetUrl); err != nil {
+			return err
+		}
+		if err := s.injectStorage(payload); err != nil {
+			return err
+		}
+	}
+
+	target := s.injectURL(c.targetUrl, payload)
+	if _, err := c.navigateTo(target); err != nil {
+		return err
+	}
+	if err := c.afterNavigation(nil); err != nil {
+		return err
+	}
+
+	if s.enabled(InjectPostMessage) {
+		canary := s.inject(InjectPostMessage, "", payload, target, []string{
+			"Open " + target,
+		})
+		value := s.render(payload, canary)
+		s.addStep(canary, fmt.Sprintf("Run window.postMessage(%s, \"*\")", strconv.Quote(value)))
+		if _, err := c.page.Eval(`(m) => window.postMessage(m, "*")`, value); err != nil {
+			return err
+		}
+		c.waitForRequestsCompletion()
+	}
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	if c.options.CheckSinks {
+		s.attachSinks()
+	}
+	return nil
+}
+
+func (s *XSSScanner) enabled(point string) bool {
+	return StringSliceContains(s.scan.InjectionPoints, point)
+}
+
+func (s *XSSScanner) render(payload, canary string) string {
+	return strings.ReplaceAll(payload, "{canary}", canary)
+}
+
+func (s *XSSScanner) inject(point, parameter, payload, pageURL string, steps []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	canary := strconv.Itoa(900000000 + s.seq)
+	s.injected[canary] = &xssInjection{
+		point:     point,
+		parameter: parameter,
+		payload:   s.render(payload, canary),
+		url:       pageURL,
+		steps:     steps,
+	}
+	return canary
+}
+
+func (s *XSSScanner) addStep(canary, step string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inj, ok := s.injected[canary]; ok {
+		inj.steps = append(inj.steps, step)
+	}
+}
+
+func (s *XSSScanner) injectURL(target, payload string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	type pending struct {
+		point, parameter, canary string
+	}
+	injections := make([]pending, 0)
+
+	if s.enabled(InjectQuery) {
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for k := range query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			canary := s.inject(InjectQuery, k, payload, "", nil)
+			query.Set(k, s.render(payload, canary))
+			injections = append(injections, pending{InjectQuery, k, canary})
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	if s.enabled(InjectFragment) {
+		canary := s.inject(InjectFragment, "", payload, "", nil)
+		u.Fragment = s.render(payload, canary)
+		injections = append(injections, pending{InjectFragment, "", canary})
+	}
+
+	injected := u.String()
+	s.mu.Lock()
+	for _, p := range injections {
+		inj := s.injected[p.canary]
+		inj.url = injected
+		inj.steps = append(inj.steps, "Open "+injected)
+	}
+	s.mu.Unlock()
+	return injected
+}
+
+func (s *XSSScanner) storageKeys() ([]string, error) {
+	keys := make([]string, 0)
+	keys = append(keys, s.scan.StorageKeys...)
+	for _, item := range s.crawler.options.BrowserLocalstorage {
+		keys = append(keys, item.Key)
+	}
+
+	res, err := s.crawler.page.Eval(`() => {
+		var keys = [];
+		try {
+			for (var i = 0; i < localStorage.length; i++) keys.push(localStorage.key(i));
+		} catch (e) { }
+		return keys;
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range res.Value.Arr() {
+		keys = append(keys, k.Str())
+	}
+	return RemoveDuplicateStrings(keys), nil
+}
+
+func (s *XSSScanner) injectStorage(payload string) error {
+	keys, err := s.storageKeys()
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	items := make(map[string]string, len(keys))
+	for _, k := range keys {
+		canary := s.inject(InjectLocalStorage, k, payload, s.crawler.targetUrl, []string{
+			"Open " + s.crawler.targetUrl,
+		})
+		items[k] = s.render(payload, canary)
+		s.addStep(canary, fmt.Sprintf("Run localStorage.setItem(%s, %s)", strconv.Quote(k), strconv.Quote(items[k])))
+	}
+
+	_, err = s.crawler.page.Eval(`(items) => {
+		for (var k in items) localStorage.setItem(k, items[k]);
+	}`, items)
+	return err
+}
+
+func (s *XSSScanner) formValue(field *InputField) (string, bool) {
+	if field.Attempt > 0 || !xssFormFieldTypes[field.Type] {
+		return "", false
+	}
+
+	s.mu.Lock()
+	payload := s.payload
+	s.mu.Unlock()
+	if payload == "" {
+		return "", false
+	}
+
+	pageURL := ""
+	if s.crawler != nil {
+		if info, err := s.crawler.page.Info(); err == nil {
+			pageURL = info.URL
+		}
+	}
+
+	canary := s.inject(InjectForm, field.Selector, payload, pageURL, []string{"Open " + pageURL})
+	value := s.render(payload, canary)
+	s.addStep(canary, fmt.Sprintf("Fill %s with %s", field.Selector, strconv.Quote(value)))
+	return value, true
+}
+
+func (s *XSSScanner) handleCallback(payload gson.JSON) (interface{}, error) {
+	canary := strings.Trim(payload.JSON("", ""), `"`)
+	s.confirm(canary, "callback")
+	return nil, nil
+}
+
+func (s *XSSScanner) confirm(canary, confirmation string) *XSSFinding {
+	var trigger *Trigger
+	if s.crawler != nil {
+		s.crawler.mu.RLock()
+		if s.crawler.trigger != nil {
+			t := *s.crawler.trigger
+			trigger = &t
+		}
+		s.crawler.mu.RUnlock()
+	}
+
+	s.mu.Lock()
+	inj, ok := s.injected[canary]
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+
+	steps := append([]string{}, inj.steps...)
+	if trigger != nil && trigger.Element != "" {
+		steps = append(steps, fmt.Sprintf("Trigger %s on %s", trigger.Event, trigger.Element))
+	}
+	finding := &XSSFinding{
+		InjectionPoint: inj.point,
+		Parameter:      inj.parameter,
+		Payload:        inj.payload,
+		URL:            inj.url,
+		Confirmation:   confirmation,
+		Trigger:        trigger,
+		Steps:          steps,
+	}
+
+	key := finding.Key()
+	if s.seen[key] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.seen[key] = true
+	s.findings = append(s.findings, finding)
+	onFinding := s.onFinding
+	s.mu.Unlock()
+
+	if onFinding != nil {
+		onFinding(finding)
+	}
+	return finding
+}
+
+func (s *XSSScanner) attachSinks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.findings {
+		if f.Sink != nil {
+			continue
+		}
+		for canary, inj := range s.injected {
+			if inj.payload == f.Payload {
+				if sinks := s.crawler.sinks.Containing(canary); len(sinks) > 0 {
+					f.Sink = sinks[0]
+				}
+				break
+			}
+		}
+	}
+}